@@ -0,0 +1,24 @@
+#!/usr/bin/env python3
+# An example datagen plugin, which reads one JSON request per line from
+# stdin and writes one JSON response per line to stdout.
+import json
+import random
+import string
+import sys
+
+
+def sku(prefix="SKU"):
+    return "{}-{}".format(prefix, "".join(random.choices(string.digits, k=6)))
+
+
+functions = {"sku": sku}
+
+for line in sys.stdin:
+    req = json.loads(line)
+    resp = {"id": req["id"]}
+    try:
+        resp["result"] = functions[req["function"]](*req.get("args", []))
+    except Exception as e:
+        resp["error"] = str(e)
+
+    print(json.dumps(resp), flush=True)
@@ -0,0 +1,29 @@
+package locale
+
+import "strings"
+
+// Address is an address whose fields are consistent with each other.
+type Address struct {
+	Number      string
+	Street      string
+	City        string
+	State       string
+	StateCode   string
+	Postcode    string
+	Country     string
+	CountryCode string
+
+	format string
+}
+
+// String returns the address formatted as it's written in its locale.
+func (a Address) String() string {
+	return strings.NewReplacer(
+		"{number}", a.Number,
+		"{street}", a.Street,
+		"{city}", a.City,
+		"{state}", a.State,
+		"{stateCode}", a.StateCode,
+		"{postcode}", a.Postcode,
+	).Replace(a.format)
+}
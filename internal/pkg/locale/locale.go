@@ -0,0 +1,208 @@
+package locale
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed data/*.json
+var data embed.FS
+
+var (
+	localesMu sync.Mutex
+	locales   = map[string]*Locale{}
+)
+
+// Locale holds the data used to generate personal data for a locale.
+//
+// Patterns replace "#" with a random digit and "?" with a random upper
+// case letter.  Formats replace placeholders, such as "{first}", with
+// generated values.
+type Locale struct {
+	Code        string `json:"-"`
+	Country     string `json:"country"`
+	CountryCode string `json:"countryCode"`
+
+	Male   []string `json:"male"`
+	Female []string `json:"female"`
+	Last   []string `json:"last"`
+
+	// NameFormat is the format of a full name, using the {first} and
+	// {last} placeholders.
+	NameFormat string `json:"nameFormat"`
+
+	Streets []string `json:"streets"`
+	Cities  []City   `json:"cities"`
+
+	// Numbers are patterns for building numbers.
+	Numbers []string `json:"numbers"`
+
+	// Phones are patterns for phone numbers.
+	Phones []string `json:"phones"`
+
+	// AddressFormat is the format of a full address, using the {number},
+	// {street}, {city}, {state}, {stateCode} and {postcode} placeholders.
+	AddressFormat string `json:"addressFormat"`
+
+	EmailDomains []string `json:"emailDomains"`
+
+	// Romanised holds the Latin spelling of names written in other
+	// scripts, for use in email addresses.
+	Romanised map[string]string `json:"romanised"`
+}
+
+// City is a city, along with the state it's in and a pattern for its
+// postcodes, so that addresses are consistent.
+type City struct {
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	StateCode string `json:"stateCode"`
+	Postcode  string `json:"postcode"`
+}
+
+// Get returns the locale for a code, such as "de_DE", or a two letter
+// country code, such as "DE".  Codes are case insensitive and may use a
+// hyphen instead of an underscore.
+func Get(code string) (*Locale, error) {
+	code = Normalise(code)
+	if len(code) == 2 {
+		for _, c := range Codes() {
+			if strings.HasSuffix(c, "_"+strings.ToUpper(code)) {
+				code = c
+				break
+			}
+		}
+	}
+
+	localesMu.Lock()
+	defer localesMu.Unlock()
+
+	if l, ok := locales[code]; ok {
+		return l, nil
+	}
+
+	b, err := data.ReadFile(path.Join("data", code+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported locale %q, expected one of %s", code, strings.Join(Codes(), ", "))
+	}
+
+	l := Locale{Code: code}
+	if err = json.Unmarshal(b, &l); err != nil {
+		return nil, errors.Wrapf(err, "parsing locale %q", code)
+	}
+
+	locales[code] = &l
+	return &l, nil
+}
+
+// Codes returns the sorted codes of the available locales.
+func Codes() []string {
+	entries, _ := data.ReadDir("data")
+
+	codes := make([]string, 0, len(entries))
+	for _, e := range entries {
+		codes = append(codes, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Normalise converts a locale code into the form used by Get, such as
+// "de-de" into "de_DE".
+func Normalise(code string) string {
+	parts := strings.SplitN(strings.ReplaceAll(code, "-", "_"), "_", 2)
+	if len(parts) != 2 {
+		return code
+	}
+	return strings.ToLower(parts[0]) + "_" + strings.ToUpper(parts[1])
+}
+
+// FirstName returns a random first name of a random gender.
+func (l *Locale) FirstName() string {
+	if rand.Intn(2) == 0 {
+		return choose(l.Male)
+	}
+	return choose(l.Female)
+}
+
+// LastName returns a random last name.
+func (l *Locale) LastName() string {
+	return choose(l.Last)
+}
+
+// Name returns a random full name.
+func (l *Locale) Name() string {
+	return l.FullName(l.FirstName(), l.LastName())
+}
+
+// FullName formats a first and last name as a full name.
+func (l *Locale) FullName(first, last string) string {
+	return strings.NewReplacer("{first}", first, "{last}", last).Replace(l.NameFormat)
+}
+
+// Street returns a random street name.
+func (l *Locale) Street() string {
+	return choose(l.Streets)
+}
+
+// City returns a random city.
+func (l *Locale) City() City {
+	return l.Cities[rand.Intn(len(l.Cities))]
+}
+
+// Postcode returns a random postcode.
+func (l *Locale) Postcode() string {
+	return Pattern(l.City().Postcode)
+}
+
+// Phone returns a random phone number.
+func (l *Locale) Phone() string {
+	return Pattern(choose(l.Phones))
+}
+
+// Address returns a random address, whose postcode and state match its
+// city.
+func (l *Locale) Address() Address {
+	city := l.City()
+
+	return Address{
+		Number:      Pattern(choose(l.Numbers)),
+		Street:      l.Street(),
+		City:        city.Name,
+		State:       city.State,
+		StateCode:   city.StateCode,
+		Postcode:    Pattern(city.Postcode),
+		Country:     l.Country,
+		CountryCode: l.CountryCode,
+		format:      l.AddressFormat,
+	}
+}
+
+// Pattern replaces each "#" in a pattern with a random digit and each "?"
+// with a random upper case letter.
+func Pattern(p string) string {
+	b := strings.Builder{}
+	for _, r := range p {
+		switch r {
+		case '#':
+			b.WriteByte(byte('0' + rand.Intn(10)))
+		case '?':
+			b.WriteByte(byte('A' + rand.Intn(26)))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func choose(s []string) string {
+	return s[rand.Intn(len(s))]
+}
@@ -0,0 +1,187 @@
+package locale
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestGet(t *testing.T) {
+	cases := []struct {
+		name     string
+		code     string
+		exp      string
+		expError bool
+	}{
+		{name: "exact", code: "de_DE", exp: "de_DE"},
+		{name: "hyphen", code: "ja-JP", exp: "ja_JP"},
+		{name: "case insensitive", code: "PT_br", exp: "pt_BR"},
+		{name: "unsupported", code: "xx_XX", expError: true},
+		{name: "not a locale", code: "german", expError: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l, err := Get(c.code)
+			test.ErrorExists(t, c.expError, err)
+			if err != nil {
+				return
+			}
+
+			test.Equals(t, c.exp, l.Code)
+		})
+	}
+}
+
+func TestLocales(t *testing.T) {
+	test.Equals(t, []string{"de_DE", "en_GB", "en_US", "ja_JP", "pt_BR"}, Codes())
+
+	for _, code := range Codes() {
+		t.Run(code, func(t *testing.T) {
+			l, err := Get(code)
+			test.ErrorExists(t, false, err)
+
+			test.Assert(t, len(l.Male) > 0 && len(l.Female) > 0 && len(l.Last) > 0)
+			test.Assert(t, len(l.Streets) > 0 && len(l.Cities) > 0)
+			test.Assert(t, len(l.Numbers) > 0 && len(l.Phones) > 0)
+			test.Assert(t, strings.Contains(l.NameFormat, "{first}"))
+			test.Assert(t, strings.Contains(l.AddressFormat, "{street}"))
+
+			for i := 0; i < 100; i++ {
+				for _, s := range []string{l.Name(), l.Street(), l.Postcode(), l.Phone(), l.Address().String()} {
+					test.Assert(t, s != "")
+					test.Assert(t, !strings.ContainsAny(s, "#?{}"))
+				}
+			}
+		})
+	}
+}
+
+func TestLocaleFormats(t *testing.T) {
+	cases := []struct {
+		code     string
+		postcode string
+		phone    string
+		address  string
+	}{
+		{code: "de_DE", postcode: `^\d{5}$`, phone: `^\+49 \d+ \d+$`, address: `^\S.* \d+a?, \d{5} \S`},
+		{code: "ja_JP", postcode: `^\d{3}-\d{4}$`, phone: `^0\d{1,2}0?-\d{3,4}-\d{4}$`, address: `^〒\d{3}-\d{4} \p{Han}`},
+		{code: "pt_BR", postcode: `^\d{5}-\d{3}$`, phone: `^\(\d{2}\) \d{4,5}-\d{4}$`, address: `, \d+ - .+/[A-Z]{2}, \d{5}-\d{3}$`},
+		{code: "en_US", postcode: `^\d{5}$`, phone: `^\(\d{3}\) 555-\d{4}$`, address: `^\d+ .+, .+, [A-Z]{2} \d{5}$`},
+		{code: "en_GB", postcode: `^[A-Z]{1,2}\d{1,2} \d[A-Z]{2}$`, phone: `^\+44 `, address: `^\d+ .+, .+ [A-Z]{1,2}\d`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.code, func(t *testing.T) {
+			l, err := Get(c.code)
+			test.ErrorExists(t, false, err)
+
+			for i := 0; i < 100; i++ {
+				test.Assert(t, regexp.MustCompile(c.postcode).MatchString(l.Postcode()))
+				test.Assert(t, regexp.MustCompile(c.phone).MatchString(l.Phone()))
+				test.Assert(t, regexp.MustCompile(c.address).MatchString(l.Address().String()))
+			}
+		})
+	}
+}
+
+func TestNameFormat(t *testing.T) {
+	ja, err := Get("ja_JP")
+	test.ErrorExists(t, false, err)
+	test.Equals(t, "山田 太郎", ja.FullName("太郎", "山田"))
+
+	de, err := Get("de_DE")
+	test.ErrorExists(t, false, err)
+	test.Equals(t, "Jürgen Müller", de.FullName("Jürgen", "Müller"))
+}
+
+func TestPattern(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		test.Assert(t, regexp.MustCompile(`^AB\d{3}-[A-Z]{2}$`).MatchString(Pattern("AB###-??")))
+	}
+}
+
+func TestNormalise(t *testing.T) {
+	test.Equals(t, "de_DE", Normalise("de-de"))
+	test.Equals(t, "en_US", Normalise("EN_us"))
+	test.Equals(t, "GB", Normalise("GB"))
+}
+
+func TestGetCountry(t *testing.T) {
+	l, err := Get("de")
+	test.ErrorExists(t, false, err)
+	test.Equals(t, "de_DE", l.Code)
+
+	_, err = Get("XX")
+	test.ErrorExists(t, true, err)
+}
+
+func TestAddress(t *testing.T) {
+	l, err := Get("en_US")
+	test.ErrorExists(t, false, err)
+
+	for i := 0; i < 1000; i++ {
+		a := l.Address()
+
+		var city City
+		for _, c := range l.Cities {
+			if c.Name == a.City {
+				city = c
+			}
+		}
+
+		test.Equals(t, city.State, a.State)
+		test.Equals(t, city.StateCode, a.StateCode)
+		test.Assert(t, regexp.MustCompile(`^`+strings.ReplaceAll(city.Postcode, "#", `\d`)+`$`).MatchString(a.Postcode))
+		test.Equals(t, "US", a.CountryCode)
+		test.Equals(t, a.Number+" "+a.Street+", "+a.City+", "+a.StateCode+" "+a.Postcode, a.String())
+	}
+}
+
+func TestPerson(t *testing.T) {
+	for _, code := range Codes() {
+		t.Run(code, func(t *testing.T) {
+			l, err := Get(code)
+			test.ErrorExists(t, false, err)
+
+			for i := 0; i < 100; i++ {
+				p := l.Person()
+
+				names := l.Male
+				if p.Gender == "female" {
+					names = l.Female
+				}
+				test.Assert(t, contains(names, p.FirstName))
+				test.Equals(t, l.FullName(p.FirstName, p.LastName), p.Name)
+				test.Equals(t, p.Name, p.String())
+
+				user := strings.Split(p.Email, "@")[0]
+				test.Assert(t, strings.HasPrefix(user, l.emailName(p.FirstName)))
+				test.Assert(t, strings.Contains(user, l.emailName(p.LastName)))
+				test.Assert(t, regexp.MustCompile(`^[a-z]+[._]?[a-z]+\d*@[a-z.-]+$`).MatchString(p.Email))
+				test.Equals(t, l.CountryCode, p.Address.CountryCode)
+			}
+		})
+	}
+}
+
+func TestEmail(t *testing.T) {
+	de, err := Get("de_DE")
+	test.ErrorExists(t, false, err)
+	test.Assert(t, regexp.MustCompile(`^juergen[._]?mueller\d*@`).MatchString(de.Email("Jürgen", "Müller")))
+
+	ja, err := Get("ja_JP")
+	test.ErrorExists(t, false, err)
+	test.Assert(t, regexp.MustCompile(`^taro[._]?yamada\d*@`).MatchString(ja.Email("Taro", "山田")))
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
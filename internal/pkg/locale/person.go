@@ -0,0 +1,74 @@
+package locale
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode"
+)
+
+// Person is a person whose name, email address, phone number and address
+// are consistent with each other.
+type Person struct {
+	FirstName string
+	LastName  string
+	Name      string
+	Gender    string
+	Email     string
+	Phone     string
+	Address   Address
+}
+
+// String returns the person's full name.
+func (p Person) String() string {
+	return p.Name
+}
+
+// latin replaces the accented Latin letters used by the locales' names
+// with their unaccented spellings.
+var latin = strings.NewReplacer(
+	"ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss",
+	"á", "a", "à", "a", "â", "a", "ã", "a",
+	"é", "e", "ê", "e", "í", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ú", "u", "ç", "c",
+)
+
+// Person returns a random person.
+func (l *Locale) Person() Person {
+	p := Person{Gender: "male", LastName: l.LastName(), Phone: l.Phone(), Address: l.Address()}
+	if rand.Intn(2) == 0 {
+		p.Gender, p.FirstName = "female", choose(l.Female)
+	} else {
+		p.FirstName = choose(l.Male)
+	}
+
+	p.Name = l.FullName(p.FirstName, p.LastName)
+	p.Email = l.Email(p.FirstName, p.LastName)
+	return p
+}
+
+// Email returns a random email address derived from a first and last
+// name.
+func (l *Locale) Email(first, last string) string {
+	user := l.emailName(first) + []string{".", "_", ""}[rand.Intn(3)] + l.emailName(last)
+	if rand.Intn(2) == 0 {
+		user += fmt.Sprint(rand.Intn(100))
+	}
+
+	return user + "@" + choose(l.EmailDomains)
+}
+
+// emailName converts a name into the characters allowed in the local part
+// of an email address.
+func (l *Locale) emailName(name string) string {
+	if r, ok := l.Romanised[name]; ok {
+		name = r
+	}
+
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return -1
+		}
+		return r
+	}, latin.Replace(strings.ToLower(name)))
+}
@@ -3,6 +3,7 @@ package parse
 import (
 	"bufio"
 	"io"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -10,12 +11,27 @@ import (
 )
 
 const (
-	commentEOF    = "-- EOF"
-	commentRepeat = "-- REPEAT"
-	commentName   = "-- NAME"
-	comment       = "-- "
+	commentEOF     = "-- EOF"
+	commentRepeat  = "-- REPEAT"
+	commentName    = "-- NAME"
+	commentOutput  = "-- OUTPUT"
+	commentColumns = "-- COLUMNS"
+	commentCopy    = "-- COPY"
+	commentInfile  = "-- INFILE"
+	commentPlugin  = "-- PLUGIN"
+	commentReturns = "-- RETURNS"
+	commentLoad    = "-- LOAD"
+	commentKeep    = "-- KEEP"
+	comment        = "-- "
 )
 
+// outputOptions holds the key=value options accepted by OUTPUT comments.
+var outputOptions = map[string]bool{"delimiter": true, "null": true, "header": true}
+
+var tablePattern = regexp.MustCompile(`^(\S+)\s*\(([^)]*)\)\s*(.*)$`)
+
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 // Block represents an instruction block in a script file.
 type Block struct {
 	// Repeat tells the application how many times to run the body.
@@ -27,6 +43,114 @@ type Block struct {
 
 	// The body of the template.
 	Body string
+
+	// Output redirects the records produced by the block to a file
+	// rather than the Runner's Sink.  It's nil for SQL blocks.
+	Output *Output
+
+	// Columns names each of the values in the records produced by the
+	// block.
+	Columns []string
+
+	// Copy bulk-loads the records produced by the block into a table
+	// rather than executing the body as SQL.  It's nil for SQL blocks.
+	Copy *Copy
+
+	// Infile bulk-loads the records produced by the block into a table
+	// using MySQL's LOAD DATA LOCAL INFILE.  It's nil for SQL blocks.
+	Infile *Infile
+
+	// Plugin launches an external process whose functions can be called
+	// from the templates of subsequent blocks.
+	Plugin *Plugin
+
+	// Returns declares the columns returned by the block, allowing rows
+	// to be simulated when there's no database to return them.
+	Returns []Column
+
+	// Load reads the rows returned by the block into memory, so they can
+	// be referenced by subsequent blocks.  It's nil for SQL blocks.
+	Load *Load
+
+	// Keep bounds the rows kept in memory for the block.  It's nil if
+	// every row is kept.
+	Keep *Keep
+}
+
+// Keep describes which of a block's rows are kept in memory.
+type Keep struct {
+	// Limit is the maximum number of rows to keep, sampled uniformly
+	// from all of those produced, 0 for no limit.
+	Limit int
+
+	// Columns to keep, all of them if empty.
+	Columns []string
+}
+
+// Load describes how the rows of a LOAD block are kept.
+type Load struct {
+	// Name the rows are kept under.
+	Name string
+
+	// Limit is the maximum number of rows to keep, 0 for no limit.
+	Limit int
+
+	// Random samples rows from all of those returned, rather than
+	// keeping the first rows.
+	Random bool
+}
+
+// Column describes a column returned by a block.
+type Column struct {
+	Name string
+
+	// Type of the values to simulate (e.g. uuid or int), which may be
+	// empty if it wasn't declared.
+	Type string
+}
+
+// Plugin describes an external generator process.
+type Plugin struct {
+	// Name used to call the plugin's functions from templates.
+	Name string
+
+	// Functions declared by the plugin, which are available to templates
+	// as <name>_<function>.
+	Functions []string
+
+	// Command and arguments used to launch the plugin.
+	Command []string
+}
+
+// Copy describes the table that a block's records are bulk-loaded into.
+// The columns being loaded are held in the block's Columns.
+type Copy struct {
+	// Table to load records into, optionally schema-qualified.
+	Table string
+}
+
+// Infile describes the table that a block's records are loaded into
+// with LOAD DATA.  The columns being loaded are held in the block's
+// Columns.
+type Infile struct {
+	// Table to load records into.
+	Table string
+
+	// Options holds any key=value options, such as field and line
+	// terminators.
+	Options map[string]string
+}
+
+// Output describes where and how the records of a block are written.
+type Output struct {
+	// Format of the output (e.g. csv).
+	Format string
+
+	// Path of the file to write to, "-" for stdout.
+	Path string
+
+	// Options holds any format-specific key=value options.
+	Options map[string]string
 }
 
 // Blocks reads an input reader line by line, parsing blocks than
@@ -41,7 +165,7 @@ func Blocks(r io.Reader) ([]Block, error) {
 		if err != nil {
 			return nil, err
 		}
-		if block.Body != "" {
+		if block.Body != "" || block.Plugin != nil {
 			output = append(output, block)
 		}
 		if !ok {
@@ -61,6 +185,67 @@ func parseBlock(scanner *bufio.Scanner) (ok bool, block Block, err error) {
 			continue
 		}
 
+		if hasDirective(t, commentOutput) {
+			var err error
+			if block.Output, err = parseOutput(t); err != nil {
+				return false, Block{}, errors.Wrap(err, "parsing output")
+			}
+			continue
+		}
+
+		if strings.HasPrefix(t, commentCopy) {
+			var err error
+			if block.Copy, block.Columns, err = parseCopy(t); err != nil {
+				return false, Block{}, errors.Wrap(err, "parsing copy")
+			}
+			continue
+		}
+
+		if strings.HasPrefix(t, commentInfile) {
+			var err error
+			if block.Infile, block.Columns, err = parseInfile(t); err != nil {
+				return false, Block{}, errors.Wrap(err, "parsing infile")
+			}
+			continue
+		}
+
+		if strings.HasPrefix(t, commentPlugin) {
+			var err error
+			if block.Plugin, err = parsePlugin(t); err != nil {
+				return false, Block{}, errors.Wrap(err, "parsing plugin")
+			}
+			continue
+		}
+
+		if strings.HasPrefix(t, commentLoad) {
+			var err error
+			if block.Load, err = parseLoad(t); err != nil {
+				return false, Block{}, errors.Wrap(err, "parsing load")
+			}
+			continue
+		}
+
+		if strings.HasPrefix(t, commentKeep) {
+			var err error
+			if block.Keep, err = parseKeep(t); err != nil {
+				return false, Block{}, errors.Wrap(err, "parsing keep")
+			}
+			continue
+		}
+
+		if strings.HasPrefix(t, commentReturns) {
+			var err error
+			if block.Returns, err = parseReturns(t); err != nil {
+				return false, Block{}, errors.Wrap(err, "parsing returns")
+			}
+			continue
+		}
+
+		if strings.HasPrefix(t, commentColumns) {
+			block.Columns = parseColumns(t)
+			continue
+		}
+
 		if strings.HasPrefix(t, commentRepeat) {
 			var err error
 			if block.Repeat, err = parseRepeat(t); err != nil {
@@ -103,3 +288,209 @@ func parseRepeat(input string) (int, error) {
 func parseName(input string) string {
 	return strings.Trim(strings.TrimPrefix(input, commentName), " \t")
 }
+
+func parseOutput(input string) (*Output, error) {
+	fields := strings.Fields(strings.TrimPrefix(input, commentOutput))
+	if len(fields) == 0 {
+		return nil, errors.New("missing output format")
+	}
+
+	output := Output{
+		Format:  strings.ToLower(fields[0]),
+		Path:    "-",
+		Options: map[string]string{},
+	}
+
+	for i, field := range fields[1:] {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) == 2 && outputOptions[kv[0]] {
+			output.Options[kv[0]] = kv[1]
+			continue
+		}
+
+		// The path is the only other field, and comes before any options.
+		if i > 0 {
+			return nil, errors.Errorf("invalid output option %q", field)
+		}
+		output.Path = field
+	}
+
+	return &output, nil
+}
+
+// hasDirective returns true if a line starts with a comment directive,
+// followed by whitespace or the end of the line.
+func hasDirective(line, directive string) bool {
+	if !strings.HasPrefix(line, directive) {
+		return false
+	}
+
+	rest := line[len(directive):]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t'
+}
+
+func parseCopy(input string) (*Copy, []string, error) {
+	table, columns, options, err := parseTable(strings.TrimPrefix(input, commentCopy))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(options) > 0 {
+		return nil, nil, errors.New("unexpected copy options")
+	}
+
+	return &Copy{Table: table}, columns, nil
+}
+
+func parseInfile(input string) (*Infile, []string, error) {
+	table, columns, options, err := parseTable(strings.TrimPrefix(input, commentInfile))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &Infile{Table: table, Options: options}, columns, nil
+}
+
+// parseTable parses a table name, followed by a parenthesised list of
+// columns and any number of key=value options.
+func parseTable(input string) (string, []string, map[string]string, error) {
+	clean := strings.Trim(input, " \t")
+
+	match := tablePattern.FindStringSubmatch(clean)
+	if match == nil {
+		return "", nil, nil, errors.Errorf("expected table (columns), got %q", clean)
+	}
+
+	columns := splitColumns(match[2])
+	if len(columns) == 0 {
+		return "", nil, nil, errors.New("missing columns")
+	}
+
+	options := map[string]string{}
+	for _, field := range strings.Fields(match[3]) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return "", nil, nil, errors.Errorf("invalid option %q", field)
+		}
+		options[kv[0]] = kv[1]
+	}
+
+	return unquoteTable(match[1]), columns, options, nil
+}
+
+// unquoteTable removes the quotes from each part of an optionally
+// schema-qualified table name.  Sinks quote names when writing them.
+func unquoteTable(table string) string {
+	parts := strings.Split(table, ".")
+	for i, p := range parts {
+		parts[i] = strings.Trim(p, "\"`")
+	}
+	return strings.Join(parts, ".")
+}
+
+func parsePlugin(input string) (*Plugin, error) {
+	fields := strings.Fields(strings.TrimPrefix(input, commentPlugin))
+	if len(fields) < 2 {
+		return nil, errors.New("expected name and command")
+	}
+
+	p := Plugin{Name: fields[0], Command: fields[1:]}
+
+	// Functions are declared after the name, as in "skus:sku,barcode".
+	if i := strings.Index(p.Name, ":"); i != -1 {
+		p.Functions = strings.Split(p.Name[i+1:], ",")
+		p.Name = p.Name[:i]
+	}
+
+	for _, name := range append([]string{p.Name}, p.Functions...) {
+		if !identPattern.MatchString(name) {
+			return nil, errors.Errorf("invalid plugin or function name %q", name)
+		}
+	}
+
+	return &p, nil
+}
+
+func parseLoad(input string) (*Load, error) {
+	fields := strings.Fields(strings.TrimPrefix(input, commentLoad))
+	if len(fields) == 0 {
+		return nil, errors.New("missing name")
+	}
+
+	load := Load{Name: fields[0]}
+	for _, field := range fields[1:] {
+		if strings.EqualFold(field, "random") {
+			load.Random = true
+			continue
+		}
+
+		limit, err := strconv.Atoi(field)
+		if err != nil || limit < 1 {
+			return nil, errors.Errorf("invalid limit %q", field)
+		}
+		load.Limit = limit
+	}
+
+	return &load, nil
+}
+
+func parseKeep(input string) (*Keep, error) {
+	clean := strings.Trim(strings.TrimPrefix(input, commentKeep), " \t")
+
+	var keep Keep
+	fields := strings.SplitN(clean, " ", 2)
+	if limit, err := strconv.Atoi(fields[0]); err == nil {
+		if limit < 0 {
+			return nil, errors.Errorf("invalid limit %d", limit)
+		}
+		keep.Limit = limit
+		clean = ""
+		if len(fields) == 2 {
+			clean = fields[1]
+		}
+	}
+
+	keep.Columns = splitColumns(clean)
+	if keep.Limit == 0 && len(keep.Columns) == 0 {
+		return nil, errors.New("expected a limit or columns")
+	}
+
+	return &keep, nil
+}
+
+func parseReturns(input string) ([]Column, error) {
+	columns := []Column{}
+	for _, c := range strings.Split(strings.TrimPrefix(input, commentReturns), ",") {
+		fields := strings.Fields(c)
+		switch len(fields) {
+		case 0:
+			continue
+		case 1:
+			columns = append(columns, Column{Name: strings.Trim(fields[0], "\"`")})
+		case 2:
+			columns = append(columns, Column{Name: strings.Trim(fields[0], "\"`"), Type: strings.ToLower(fields[1])})
+		default:
+			return nil, errors.Errorf("expected name and type, got %q", strings.TrimSpace(c))
+		}
+	}
+
+	if len(columns) == 0 {
+		return nil, errors.New("missing columns")
+	}
+	return columns, nil
+}
+
+func parseColumns(input string) []string {
+	return splitColumns(strings.TrimPrefix(input, commentColumns))
+}
+
+// splitColumns splits a comma-separated list of column names, removing
+// any quotes, which sinks add back when writing them.
+func splitColumns(input string) []string {
+	columns := []string{}
+	for _, c := range strings.Split(input, ",") {
+		if c = strings.Trim(c, " \t\"`"); c != "" {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
@@ -277,3 +277,420 @@ type errReader struct {
 func (r *errReader) Read(_ []byte) (int, error) {
 	return 0, r.err
 }
+
+func TestBlocksOutput(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		exp      *Output
+		expError bool
+	}{
+		{
+			name:  "no output",
+			input: `insert into "t" ("a") values ('a');`,
+		},
+		{
+			name: "format only",
+			input: `-- OUTPUT csv
+			{{record 1}}`,
+			exp: &Output{Format: "csv", Path: "-", Options: map[string]string{}},
+		},
+		{
+			name: "format and path",
+			input: `-- OUTPUT CSV owners.csv
+			{{record 1}}`,
+			exp: &Output{Format: "csv", Path: "owners.csv", Options: map[string]string{}},
+		},
+		{
+			name: "format, path and options",
+			input: `-- OUTPUT csv owners.csv delimiter=; null=\N
+			{{record 1}}`,
+			exp: &Output{Format: "csv", Path: "owners.csv", Options: map[string]string{"delimiter": ";", "null": `\N`}},
+		},
+		{
+			name: "missing format",
+			input: `-- OUTPUT
+			{{record 1}}`,
+			expError: true,
+		},
+		{
+			name: "options without path",
+			input: `-- OUTPUT csv header=false
+			{{record 1}}`,
+			exp: &Output{Format: "csv", Path: "-", Options: map[string]string{"header": "false"}},
+		},
+		{
+			name: "path containing equals",
+			input: `-- OUTPUT csv out=1.csv null=NULL
+			{{record 1}}`,
+			exp: &Output{Format: "csv", Path: "out=1.csv", Options: map[string]string{"null": "NULL"}},
+		},
+		{
+			name: "directive without word boundary",
+			input: `-- OUTPUTX csv
+			{{record 1}}`,
+		},
+		{
+			name: "invalid option",
+			input: `-- OUTPUT csv owners.csv header
+			{{record 1}}`,
+			expError: true,
+		},
+		{
+			name: "unknown option",
+			input: `-- OUTPUT csv owners.csv quote=true
+			{{record 1}}`,
+			expError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			blocks, err := Blocks(strings.NewReader(c.input))
+			test.ErrorExists(t, c.expError, err)
+			if err != nil {
+				return
+			}
+
+			test.Equals(t, 1, len(blocks))
+			test.Equals(t, c.exp, blocks[0].Output)
+		})
+	}
+}
+
+func TestBlocksColumns(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		exp   []string
+	}{
+		{
+			name:  "no columns",
+			input: `{{record 1}}`,
+		},
+		{
+			name: "one column",
+			input: `-- COLUMNS id
+			{{record 1}}`,
+			exp: []string{"id"},
+		},
+		{
+			name: "multiple columns",
+			input: `-- COLUMNS id,name , email
+			{{record 1 2 3}}`,
+			exp: []string{"id", "name", "email"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			blocks, err := Blocks(strings.NewReader(c.input))
+			test.ErrorExists(t, false, err)
+			test.Equals(t, c.exp, blocks[0].Columns)
+		})
+	}
+}
+
+func TestBlocksCopy(t *testing.T) {
+	cases := []struct {
+		name       string
+		input      string
+		exp        *Copy
+		expColumns []string
+		expError   bool
+	}{
+		{
+			name:  "no copy",
+			input: `insert into "t" ("a") values ('a');`,
+		},
+		{
+			name: "table and columns",
+			input: `-- COPY owner (id, name)
+			{{record 1 2}}`,
+			exp:        &Copy{Table: "owner"},
+			expColumns: []string{"id", "name"},
+		},
+		{
+			name: "schema-qualified table and quoted columns",
+			input: `-- COPY public.owner ("id","name")
+			{{record 1 2}}`,
+			exp:        &Copy{Table: "public.owner"},
+			expColumns: []string{"id", "name"},
+		},
+		{
+			name: "quoted mixed-case table and reserved columns",
+			input: `-- COPY "Sales"."Order" ("Id", "order")
+			{{record 1 2}}`,
+			exp:        &Copy{Table: "Sales.Order"},
+			expColumns: []string{"Id", "order"},
+		},
+		{
+			name: "missing columns",
+			input: `-- COPY owner
+			{{record 1}}`,
+			expError: true,
+		},
+		{
+			name: "empty columns",
+			input: `-- COPY owner ()
+			{{record 1}}`,
+			expError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			blocks, err := Blocks(strings.NewReader(c.input))
+			test.ErrorExists(t, c.expError, err)
+			if err != nil {
+				return
+			}
+
+			test.Equals(t, c.exp, blocks[0].Copy)
+			test.Equals(t, c.expColumns, blocks[0].Columns)
+		})
+	}
+}
+
+func TestBlocksInfile(t *testing.T) {
+	cases := []struct {
+		name       string
+		input      string
+		exp        *Infile
+		expColumns []string
+		expError   bool
+	}{
+		{
+			name: "table and columns",
+			input: `-- INFILE pet (pid, name)
+			{{record 1 2}}`,
+			exp:        &Infile{Table: "pet", Options: map[string]string{}},
+			expColumns: []string{"pid", "name"},
+		},
+		{
+			name: "terminators",
+			input: `-- INFILE pet (pid, name) fields=, lines=\r\n
+			{{record 1 2}}`,
+			exp:        &Infile{Table: "pet", Options: map[string]string{"fields": ",", "lines": `\r\n`}},
+			expColumns: []string{"pid", "name"},
+		},
+		{
+			name: "invalid option",
+			input: `-- INFILE pet (pid, name) fields
+			{{record 1 2}}`,
+			expError: true,
+		},
+		{
+			name: "missing columns",
+			input: `-- INFILE pet
+			{{record 1}}`,
+			expError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			blocks, err := Blocks(strings.NewReader(c.input))
+			test.ErrorExists(t, c.expError, err)
+			if err != nil {
+				return
+			}
+
+			test.Equals(t, c.exp, blocks[0].Infile)
+			test.Equals(t, c.expColumns, blocks[0].Columns)
+		})
+	}
+}
+
+func TestBlocksPlugin(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		exp      []*Plugin
+		expError bool
+	}{
+		{
+			name:  "plugin without body",
+			input: `-- PLUGIN skus ./gen-sku.py`,
+			exp:   []*Plugin{{Name: "skus", Command: []string{"./gen-sku.py"}}},
+		},
+		{
+			name: "plugin with arguments followed by block",
+			input: `-- PLUGIN skus python3 gen-sku.py --prefix X
+
+			select '{{plugin "skus" "sku"}}';`,
+			exp: []*Plugin{{Name: "skus", Command: []string{"python3", "gen-sku.py", "--prefix", "X"}}, nil},
+		},
+		{
+			name:  "plugin with functions",
+			input: `-- PLUGIN skus:sku,barcode ./gen-sku.py`,
+			exp:   []*Plugin{{Name: "skus", Functions: []string{"sku", "barcode"}, Command: []string{"./gen-sku.py"}}},
+		},
+		{
+			name:     "missing command",
+			input:    `-- PLUGIN skus`,
+			expError: true,
+		},
+		{
+			name:     "invalid function name",
+			input:    `-- PLUGIN skus:sku,bar-code ./gen-sku.py`,
+			expError: true,
+		},
+		{
+			name:     "empty function name",
+			input:    `-- PLUGIN skus: ./gen-sku.py`,
+			expError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			blocks, err := Blocks(strings.NewReader(c.input))
+			test.ErrorExists(t, c.expError, err)
+			if err != nil {
+				return
+			}
+
+			test.Equals(t, len(c.exp), len(blocks))
+			for i, b := range blocks {
+				test.Equals(t, c.exp[i], b.Plugin)
+			}
+		})
+	}
+}
+
+func TestBlocksReturns(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		exp      []Column
+		expError bool
+	}{
+		{
+			name:  "names and types",
+			input: "-- RETURNS id uuid, \"email\" string\nselect 1",
+			exp:   []Column{{Name: "id", Type: "uuid"}, {Name: "email", Type: "string"}},
+		},
+		{
+			name:  "names without types",
+			input: "-- RETURNS id, email INT\nselect 1",
+			exp:   []Column{{Name: "id"}, {Name: "email", Type: "int"}},
+		},
+		{
+			name:     "missing columns",
+			input:    "-- RETURNS\nselect 1",
+			expError: true,
+		},
+		{
+			name:     "too many fields",
+			input:    "-- RETURNS id uuid primary\nselect 1",
+			expError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			blocks, err := Blocks(strings.NewReader(c.input))
+			test.ErrorExists(t, c.expError, err)
+			if err != nil {
+				return
+			}
+
+			test.Equals(t, c.exp, blocks[0].Returns)
+		})
+	}
+}
+
+func TestBlocksLoad(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		exp      *Load
+		expError bool
+	}{
+		{
+			name:  "name only",
+			input: "-- LOAD customer\nselect id from customer",
+			exp:   &Load{Name: "customer"},
+		},
+		{
+			name:  "limit",
+			input: "-- LOAD customer 100\nselect id from customer",
+			exp:   &Load{Name: "customer", Limit: 100},
+		},
+		{
+			name:  "random limit",
+			input: "-- LOAD customer 100 random\nselect id from customer",
+			exp:   &Load{Name: "customer", Limit: 100, Random: true},
+		},
+		{
+			name:     "missing name",
+			input:    "-- LOAD\nselect id from customer",
+			expError: true,
+		},
+		{
+			name:     "invalid limit",
+			input:    "-- LOAD customer -1\nselect id from customer",
+			expError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			blocks, err := Blocks(strings.NewReader(c.input))
+			test.ErrorExists(t, c.expError, err)
+			if err != nil {
+				return
+			}
+
+			test.Equals(t, c.exp, blocks[0].Load)
+		})
+	}
+}
+
+func TestBlocksKeep(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		exp      *Keep
+		expError bool
+	}{
+		{
+			name:  "limit",
+			input: "-- KEEP 100000\nselect 1",
+			exp:   &Keep{Limit: 100000, Columns: []string{}},
+		},
+		{
+			name:  "limit and columns",
+			input: "-- KEEP 100 id, \"email\"\nselect 1",
+			exp:   &Keep{Limit: 100, Columns: []string{"id", "email"}},
+		},
+		{
+			name:  "columns",
+			input: "-- KEEP id\nselect 1",
+			exp:   &Keep{Columns: []string{"id"}},
+		},
+		{
+			name:     "nothing to keep",
+			input:    "-- KEEP\nselect 1",
+			expError: true,
+		},
+		{
+			name:     "negative limit",
+			input:    "-- KEEP -1\nselect 1",
+			expError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			blocks, err := Blocks(strings.NewReader(c.input))
+			test.ErrorExists(t, c.expError, err)
+			if err != nil {
+				return
+			}
+
+			test.Equals(t, c.exp, blocks[0].Keep)
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// request is written to a plugin's stdin, one per line.
+type request struct {
+	ID       uint64        `json:"id"`
+	Function string        `json:"function"`
+	Args     []interface{} `json:"args"`
+}
+
+// response is read from a plugin's stdout, one per line.
+type response struct {
+	ID     uint64      `json:"id"`
+	Result interface{} `json:"result"`
+	Error  string      `json:"error"`
+}
+
+// responseBuffer is the number of responses that can be held without a
+// caller receiving them, so that a response that arrives just as its call
+// times out doesn't block the reader until the next call discards it.
+const responseBuffer = 64
+
+// Plugin is an external process that generates values for templates
+// over a line-delimited JSON protocol on its stdin and stdout.
+type Plugin struct {
+	name    string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	timeout time.Duration
+
+	mu        sync.Mutex
+	lastID    uint64
+	waiting   uint64 // The ID of the call awaiting a response, accessed atomically.
+	responses chan response
+	done      chan struct{}
+	readErr   error
+}
+
+// Start launches a plugin process, which is kept running until it's
+// closed.  Calls to the plugin that don't receive a response within the
+// timeout fail.
+func Start(name string, command []string, timeout time.Duration) (*Plugin, error) {
+	if len(command) == 0 {
+		return nil, errors.New("missing plugin command")
+	}
+
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "opening stdin")
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "opening stdout")
+	}
+
+	if err = cmd.Start(); err != nil {
+		return nil, errors.Wrapf(err, "starting plugin %q", name)
+	}
+
+	p := &Plugin{
+		name:      name,
+		cmd:       cmd,
+		stdin:     stdin,
+		timeout:   timeout,
+		responses: make(chan response, responseBuffer),
+		done:      make(chan struct{}),
+	}
+
+	go p.read(stdout)
+	return p, nil
+}
+
+// read decodes responses from the plugin until its stdout is closed.
+func (p *Plugin) read(stdout io.Reader) {
+	defer close(p.done)
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var resp response
+		dec := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		dec.UseNumber()
+		if err := dec.Decode(&resp); err != nil {
+			p.readErr = errors.Wrapf(err, "invalid response %q", scanner.Text())
+			return
+		}
+
+		// Calls are made one at a time, so a response to any other call
+		// is to one that's timed out, and is dropped rather than blocking
+		// the reader.
+		if resp.ID != atomic.LoadUint64(&p.waiting) {
+			continue
+		}
+		p.responses <- resp
+	}
+
+	p.readErr = scanner.Err()
+}
+
+// Call invokes a function in the plugin, returning its result.
+func (p *Plugin) Call(function string, args ...interface{}) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lastID++
+	req := request{ID: p.lastID, Function: function, Args: args}
+
+	atomic.StoreUint64(&p.waiting, req.ID)
+	defer atomic.StoreUint64(&p.waiting, 0)
+	if req.Args == nil {
+		req.Args = []interface{}{}
+	}
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding request")
+	}
+
+	if _, err = p.stdin.Write(append(b, '\n')); err != nil {
+		return nil, errors.Wrapf(err, "writing to plugin %q", p.name)
+	}
+
+	timeout := time.NewTimer(p.timeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case resp := <-p.responses:
+			// Responses to calls that have already timed out are discarded.
+			if resp.ID != req.ID {
+				continue
+			}
+			return p.result(resp)
+		case <-p.done:
+			// The plugin may have responded just before exiting, in which
+			// case the response is already buffered.
+			if resp, ok := p.buffered(req.ID); ok {
+				return p.result(resp)
+			}
+			if p.readErr != nil {
+				return nil, errors.Wrapf(p.readErr, "reading from plugin %q", p.name)
+			}
+			return nil, fmt.Errorf("plugin %q exited", p.name)
+		case <-timeout.C:
+			return nil, fmt.Errorf("plugin %q timed out calling %q after %s", p.name, function, p.timeout)
+		}
+	}
+}
+
+// buffered returns the response to a call, if it's been received but not
+// yet read, discarding any responses before it.
+func (p *Plugin) buffered(id uint64) (response, bool) {
+	for {
+		select {
+		case resp := <-p.responses:
+			if resp.ID == id {
+				return resp, true
+			}
+		default:
+			return response{}, false
+		}
+	}
+}
+
+func (p *Plugin) result(resp response) (interface{}, error) {
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %q: %s", p.name, resp.Error)
+	}
+	return resp.Result, nil
+}
+
+// Close closes the plugin's stdin, signalling it to exit, and waits for
+// it to do so.  Plugins that don't exit within the timeout are killed.
+func (p *Plugin) Close() error {
+	p.stdin.Close()
+
+	exited := make(chan error, 1)
+	go func() {
+		// Drain any unread responses so the process isn't blocked writing.
+		for {
+			select {
+			case <-p.responses:
+			case <-p.done:
+				exited <- p.cmd.Wait()
+				return
+			}
+		}
+	}()
+
+	select {
+	case err := <-exited:
+		return errors.Wrapf(err, "waiting for plugin %q", p.name)
+	case <-time.After(p.timeout):
+		p.cmd.Process.Kill()
+		<-exited
+		return fmt.Errorf("plugin %q killed after failing to exit within %s", p.name, p.timeout)
+	}
+}
@@ -0,0 +1,168 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+// TestHelperProcess isn't a real test, it's used as a plugin process by
+// the other tests in this package.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("DATAGEN_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			fmt.Println("not json")
+			continue
+		}
+
+		resp := response{ID: req.ID}
+		switch req.Function {
+		case "upper":
+			resp.Result = strings.ToUpper(req.Args[0].(string))
+		case "sum":
+			resp.Result = req.Args[0].(float64) + req.Args[1].(float64)
+		case "sleep":
+			time.Sleep(time.Second)
+			resp.Result = "slept"
+		case "garbage":
+			fmt.Println("not json")
+			continue
+		default:
+			resp.Error = fmt.Sprintf("unknown function %q", req.Function)
+		}
+
+		b, _ := json.Marshal(resp)
+		fmt.Println(string(b))
+	}
+	os.Exit(0)
+}
+
+func startHelper(t *testing.T, timeout time.Duration) *Plugin {
+	t.Setenv("DATAGEN_HELPER_PROCESS", "1")
+
+	p, err := Start("helper", []string{os.Args[0], "-test.run=TestHelperProcess"}, timeout)
+	if err != nil {
+		t.Fatalf("error starting helper: %v", err)
+	}
+	return p
+}
+
+func TestCall(t *testing.T) {
+	cases := []struct {
+		name     string
+		function string
+		args     []interface{}
+		exp      interface{}
+		expError bool
+	}{
+		{name: "string result", function: "upper", args: []interface{}{"sku"}, exp: "SKU"},
+		{name: "numeric result", function: "sum", args: []interface{}{1, 2.5}, exp: json.Number("3.5")},
+		{name: "error result", function: "missing", expError: true},
+	}
+
+	p := startHelper(t, time.Second)
+	defer p.Close()
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			act, err := p.Call(c.function, c.args...)
+			test.ErrorExists(t, c.expError, err)
+			test.Equals(t, c.exp, act)
+		})
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	p := startHelper(t, time.Millisecond*100)
+	defer p.Close()
+
+	_, err := p.Call("sleep")
+	test.ErrorExists(t, true, err)
+
+	// The late response to the timed out call is dropped without
+	// blocking the reader.
+	time.Sleep(time.Second)
+	test.Equals(t, 0, len(p.responses))
+
+	act, err := p.Call("upper", "a")
+	test.ErrorExists(t, false, err)
+	test.Equals(t, "A", act)
+}
+
+// exitingStdin stands in for the stdin of a plugin that responds to a
+// request and exits before the call waits for its response.
+type exitingStdin struct {
+	p *Plugin
+}
+
+func (s exitingStdin) Write(b []byte) (int, error) {
+	var req request
+	if err := json.Unmarshal(b, &req); err != nil {
+		return 0, err
+	}
+
+	s.p.responses <- response{ID: req.ID, Result: "last"}
+	close(s.p.done)
+	return len(b), nil
+}
+
+func (s exitingStdin) Close() error {
+	return nil
+}
+
+func TestCallResponseBeforeExit(t *testing.T) {
+	// The response and the exit are both ready when the call waits, so
+	// it's repeated to catch the exit being chosen over the response.
+	for i := 0; i < 20; i++ {
+		p := &Plugin{
+			name:      "exiting",
+			timeout:   time.Second,
+			responses: make(chan response, responseBuffer),
+			done:      make(chan struct{}),
+		}
+		p.stdin = exitingStdin{p: p}
+
+		act, err := p.Call("last")
+		test.ErrorExists(t, false, err)
+		test.Equals(t, "last", act)
+	}
+}
+
+func TestCallInvalidResponse(t *testing.T) {
+	p := startHelper(t, time.Second)
+	defer p.Close()
+
+	_, err := p.Call("garbage")
+	test.ErrorExists(t, true, err)
+}
+
+func TestClose(t *testing.T) {
+	p := startHelper(t, time.Second)
+
+	_, err := p.Call("upper", "a")
+	test.ErrorExists(t, false, err)
+	test.ErrorExists(t, false, p.Close())
+
+	_, err = p.Call("upper", "a")
+	test.ErrorExists(t, true, err)
+}
+
+func TestStartError(t *testing.T) {
+	_, err := Start("missing", []string{"./does-not-exist"}, time.Second)
+	test.ErrorExists(t, true, err)
+
+	_, err = Start("empty", nil, time.Second)
+	test.ErrorExists(t, true, err)
+}
@@ -0,0 +1,375 @@
+package random
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type cardBrand struct {
+	prefixes []string
+	length   int
+}
+
+var cardBrands = map[string]cardBrand{
+	"visa":       {prefixes: []string{"4"}, length: 16},
+	"mastercard": {prefixes: []string{"51", "52", "53", "54", "55", "2221", "2720"}, length: 16},
+	"amex":       {prefixes: []string{"34", "37"}, length: 15},
+	"discover":   {prefixes: []string{"6011", "644", "65"}, length: 16},
+	"jcb":        {prefixes: []string{"3528", "3589"}, length: 16},
+	"diners":     {prefixes: []string{"36", "38"}, length: 14},
+}
+
+// ibanFormats holds the format of each country's basic bank account
+// number (BBAN), as a sequence of lengths and character types: "n" for
+// digits, "a" for upper case letters and "c" for either.
+var ibanFormats = map[string]string{
+	"AT": "16n",
+	"BE": "12n",
+	"CH": "5n12c",
+	"DE": "18n",
+	"DK": "14n",
+	"ES": "20n",
+	"FI": "14n",
+	"FR": "10n11c2n",
+	"GB": "4a14n",
+	"IE": "4a14n",
+	"IT": "1a10n12c",
+	"LU": "3n13c",
+	"NL": "4a10n",
+	"NO": "11n",
+	"PL": "24n",
+	"PT": "21n",
+	"SE": "20n",
+}
+
+// nationalChecks replaces the national check digits in the BBANs of the
+// countries that have them, returning false if there's no valid check
+// digit for the BBAN.
+var nationalChecks = map[string]func(bban string) (string, bool){
+	"BE": checkBE,
+	"ES": checkES,
+	"FI": checkFI,
+	"FR": checkFR,
+	"IT": checkIT,
+	"NO": checkNO,
+	"PT": checkPT,
+}
+
+const (
+	digitChars  = "0123456789"
+	letterChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+)
+
+// routingPrefixes holds the valid first two digits of US routing numbers.
+var routingPrefixes = func() []int {
+	var prefixes []int
+	for _, r := range [][2]int{{1, 12}, {21, 32}, {61, 72}, {80, 80}} {
+		for p := r[0]; p <= r[1]; p++ {
+			prefixes = append(prefixes, p)
+		}
+	}
+	return prefixes
+}()
+
+// Card returns a random card number with a valid Luhn check digit for a
+// given brand, such as "visa" or "amex".
+func Card(brand string) (string, error) {
+	b, ok := cardBrands[strings.ToLower(brand)]
+	if !ok {
+		return "", fmt.Errorf("unsupported card brand %q, expected one of %s", brand, keys(cardBrands))
+	}
+
+	number := b.prefixes[rand.Intn(len(b.prefixes))]
+	number += digits(b.length - len(number) - 1)
+
+	return number + strconv.Itoa(luhn(number)), nil
+}
+
+// IBAN returns a random IBAN for a given country code, with valid mod-97
+// check digits.  For countries whose BBANs include national check digits
+// that are calculated from the BBAN alone, these are valid too.
+func IBAN(country string) (string, error) {
+	country = strings.ToUpper(country)
+	format, ok := ibanFormats[country]
+	if !ok {
+		return "", fmt.Errorf("unsupported IBAN country %q, expected one of %s", country, keys(ibanFormats))
+	}
+
+	account, err := bban(format)
+	if err != nil {
+		return "", err
+	}
+
+	if check, ok := nationalChecks[country]; ok {
+		// Some account numbers have no valid check digit, so new ones are
+		// generated until one does.
+		for valid := false; !valid; {
+			if account, valid = check(account); !valid {
+				if account, err = bban(format); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+
+	return fmt.Sprintf("%s%02d%s", country, ibanCheck(country, account), account), nil
+}
+
+// ISBN10 returns a random ISBN-10 with a valid check digit.
+func ISBN10() string {
+	number := digits(9)
+
+	var sum int
+	for i, d := range number {
+		sum += (10 - i) * int(d-'0')
+	}
+
+	switch check := (11 - sum%11) % 11; check {
+	case 10:
+		return number + "X"
+	default:
+		return number + strconv.Itoa(check)
+	}
+}
+
+// ISBN13 returns a random ISBN-13 with a valid check digit.
+func ISBN13() string {
+	number := []string{"978", "979"}[rand.Intn(2)] + digits(9)
+	return number + strconv.Itoa(ean(number))
+}
+
+// EAN13 returns a random EAN-13 barcode number with a valid check digit.
+func EAN13() string {
+	number := digits(12)
+	return number + strconv.Itoa(ean(number))
+}
+
+// RoutingNumber returns a random US bank routing number (ABA routing
+// transit number) with a valid check digit.
+func RoutingNumber() string {
+	number := fmt.Sprintf("%02d", routingPrefixes[rand.Intn(len(routingPrefixes))]) + digits(6)
+
+	weights := []int{3, 7, 1, 3, 7, 1, 3, 7}
+	var sum int
+	for i, d := range number {
+		sum += weights[i] * int(d-'0')
+	}
+
+	return number + strconv.Itoa((10-sum%10)%10)
+}
+
+// luhn returns the Luhn check digit for a number.
+func luhn(number string) int {
+	var sum int
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+
+		// Digits are doubled from the rightmost, as the check digit will
+		// be appended to the right of them.
+		if (len(number)-i)%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+
+	return (10 - sum%10) % 10
+}
+
+// ean returns the EAN check digit for a number, which is also used by
+// ISBN-13.
+func ean(number string) int {
+	var sum int
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if (len(number)-i)%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+
+	return (10 - sum%10) % 10
+}
+
+// checkBE sets the check digits of a Belgian account number, which are
+// the rest of its first ten digits divided by 97.
+func checkBE(bban string) (string, bool) {
+	check := mod97(bban[:10])
+	if check == 0 {
+		check = 97
+	}
+	return fmt.Sprintf("%s%02d", bban[:10], check), true
+}
+
+// checkES sets the two check digits of a Spanish account number, which
+// cover the bank and branch codes and the account number respectively.
+func checkES(bban string) (string, bool) {
+	return bban[:8] + strconv.Itoa(mod11ES("00"+bban[:8])) + strconv.Itoa(mod11ES(bban[10:])) + bban[10:], true
+}
+
+func mod11ES(number string) int {
+	weights := []int{1, 2, 4, 8, 5, 10, 9, 7, 3, 6}
+
+	var sum int
+	for i, d := range number {
+		sum += weights[i] * int(d-'0')
+	}
+
+	switch check := 11 - sum%11; check {
+	case 11:
+		return 0
+	case 10:
+		return 1
+	default:
+		return check
+	}
+}
+
+// checkFI sets the Luhn check digit of a Finnish account number.
+func checkFI(bban string) (string, bool) {
+	return bban[:13] + strconv.Itoa(luhn(bban[:13])), true
+}
+
+// checkFR sets the RIB key of a French account number, where letters in
+// the account number count as the digits 1 to 9 in turn.
+func checkFR(bban string) (string, bool) {
+	account := strings.Map(func(c rune) rune {
+		if c >= 'A' && c <= 'Z' {
+			// A-I, J-R and S-Z are 1-9, with S starting at 2.
+			n := c - 'A'
+			if c >= 'S' {
+				n++
+			}
+			return '1' + n%9
+		}
+		return c
+	}, bban[10:21])
+
+	bank, _ := strconv.ParseInt(bban[:5], 10, 64)
+	branch, _ := strconv.ParseInt(bban[5:10], 10, 64)
+	number, _ := strconv.ParseInt(account, 10, 64)
+
+	return fmt.Sprintf("%s%02d", bban[:21], 97-(89*bank+15*branch+3*number)%97), true
+}
+
+// cinOdd holds the values of the characters in odd positions of an
+// Italian account number, indexed by their value in even positions.
+var cinOdd = []int{1, 0, 5, 7, 9, 13, 15, 17, 19, 21, 2, 4, 18, 20, 11, 3, 6, 8, 12, 14, 16, 10, 22, 25, 24, 23}
+
+// checkIT sets the CIN of an Italian account number, the letter that
+// precedes its bank, branch and account numbers.
+func checkIT(bban string) (string, bool) {
+	var sum int
+	for i, c := range bban[1:] {
+		v := int(c - '0')
+		if c >= 'A' && c <= 'Z' {
+			v = int(c - 'A')
+		}
+
+		if i%2 == 0 {
+			v = cinOdd[v]
+		}
+		sum += v
+	}
+
+	return string(rune('A'+sum%26)) + bban[1:], true
+}
+
+// checkNO sets the mod-11 check digit of a Norwegian account number.
+// Account numbers whose check digit would be 10 aren't valid.
+func checkNO(bban string) (string, bool) {
+	weights := []int{5, 4, 3, 2, 7, 6, 5, 4, 3, 2}
+
+	var sum int
+	for i, d := range bban[:10] {
+		sum += weights[i] * int(d-'0')
+	}
+
+	switch check := 11 - sum%11; check {
+	case 11:
+		return bban[:10] + "0", true
+	case 10:
+		return "", false
+	default:
+		return bban[:10] + strconv.Itoa(check), true
+	}
+}
+
+// checkPT sets the mod-97 check digits of a Portuguese account number.
+func checkPT(bban string) (string, bool) {
+	return fmt.Sprintf("%s%02d", bban[:19], 98-mod97(bban[:19]+"00")), true
+}
+
+// mod97 returns the rest of a number of any length divided by 97.
+func mod97(number string) int {
+	var rest int
+	for _, d := range number {
+		rest = (rest*10 + int(d-'0')) % 97
+	}
+	return rest
+}
+
+// ibanCheck returns the check digits of an IBAN.
+func ibanCheck(country, bban string) int {
+	n, _ := new(big.Int).SetString(ibanDigits(bban+country+"00"), 10)
+	return 98 - int(new(big.Int).Mod(n, big.NewInt(97)).Int64())
+}
+
+// ibanDigits converts the letters in an IBAN to numbers, where A is 10
+// and Z is 35.
+func ibanDigits(s string) string {
+	b := strings.Builder{}
+	for _, c := range s {
+		if c >= 'A' && c <= 'Z' {
+			b.WriteString(strconv.Itoa(int(c-'A') + 10))
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+func bban(format string) (string, error) {
+	b := strings.Builder{}
+	for format != "" {
+		i := strings.IndexAny(format, "nac")
+		if i < 1 {
+			return "", fmt.Errorf("invalid BBAN format %q", format)
+		}
+
+		n, err := strconv.Atoi(format[:i])
+		if err != nil {
+			return "", fmt.Errorf("invalid BBAN format %q", format)
+		}
+
+		switch format[i] {
+		case 'n':
+			b.WriteString(String(int64(n), int64(n), digitChars))
+		case 'a':
+			b.WriteString(String(int64(n), int64(n), letterChars))
+		case 'c':
+			b.WriteString(String(int64(n), int64(n), digitChars+letterChars))
+		}
+		format = format[i+1:]
+	}
+
+	return b.String(), nil
+}
+
+func digits(n int) string {
+	return String(int64(n), int64(n), digitChars)
+}
+
+func keys[T any](m map[string]T) string {
+	k := make([]string, 0, len(m))
+	for key := range m {
+		k = append(k, key)
+	}
+	sort.Strings(k)
+	return strings.Join(k, ", ")
+}
@@ -0,0 +1,207 @@
+package random
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestCard(t *testing.T) {
+	cases := []struct {
+		brand    string
+		length   int
+		prefixes []string
+		expError bool
+	}{
+		{brand: "visa", length: 16, prefixes: []string{"4"}},
+		{brand: "Mastercard", length: 16, prefixes: []string{"5", "2"}},
+		{brand: "amex", length: 15, prefixes: []string{"34", "37"}},
+		{brand: "discover", length: 16, prefixes: []string{"6"}},
+		{brand: "jcb", length: 16, prefixes: []string{"35"}},
+		{brand: "diners", length: 14, prefixes: []string{"36", "38"}},
+		{brand: "unknown", expError: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.brand, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				number, err := Card(c.brand)
+				test.ErrorExists(t, c.expError, err)
+				if err != nil {
+					return
+				}
+
+				test.Equals(t, c.length, len(number))
+				test.Assert(t, hasAnyPrefix(number, c.prefixes))
+				test.Assert(t, validLuhn(number))
+			}
+		})
+	}
+}
+
+func TestLuhn(t *testing.T) {
+	test.Equals(t, 3, luhn("7992739871"))
+	test.Equals(t, 1, luhn("411111111111111"))
+}
+
+func TestNationalChecks(t *testing.T) {
+	cases := []struct {
+		country string
+		bban    string
+		exp     string
+	}{
+		{country: "BE", bban: "539007547000", exp: "539007547034"},
+		{country: "ES", bban: "21000418000200051332", exp: "21000418450200051332"},
+		{country: "FI", bban: "12345600000780", exp: "12345600000785"},
+		{country: "FR", bban: "20041010050500013M02600", exp: "20041010050500013M02606"},
+		{country: "IT", bban: "A0542811101000000123456", exp: "X0542811101000000123456"},
+		{country: "NO", bban: "86011117940", exp: "86011117947"},
+		{country: "PT", bban: "000201231234567890100", exp: "000201231234567890154"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.country, func(t *testing.T) {
+			act, valid := nationalChecks[c.country](c.bban)
+			test.Assert(t, valid)
+			test.Equals(t, c.exp, act)
+		})
+	}
+
+	// Norwegian account numbers whose check digit would be 10 are invalid.
+	_, valid := checkNO("00000000060")
+	test.Assert(t, !valid)
+}
+
+func TestIBAN(t *testing.T) {
+	test.Equals(t, 82, ibanCheck("GB", "WEST12345698765432"))
+	test.Equals(t, 89, ibanCheck("DE", "370400440532013000"))
+
+	for country, format := range ibanFormats {
+		t.Run(country, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				iban, err := IBAN(strings.ToLower(country))
+				test.ErrorExists(t, false, err)
+
+				test.Assert(t, strings.HasPrefix(iban, country))
+				test.Assert(t, regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]+$`).MatchString(iban))
+				test.Equals(t, 4+bbanLength(format), len(iban))
+				test.Assert(t, validIBAN(iban))
+
+				if check, ok := nationalChecks[country]; ok {
+					act, valid := check(iban[4:])
+					test.Assert(t, valid)
+					test.Equals(t, iban[4:], act)
+				}
+			}
+		})
+	}
+
+	_, err := IBAN("XX")
+	test.ErrorExists(t, true, err)
+}
+
+func TestISBN10(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		isbn := ISBN10()
+		test.Equals(t, 10, len(isbn))
+
+		var sum int
+		for j, c := range isbn {
+			d := int(c - '0')
+			if c == 'X' {
+				test.Equals(t, 9, j)
+				d = 10
+			}
+			sum += (10 - j) * d
+		}
+		test.Equals(t, 0, sum%11)
+	}
+}
+
+func TestISBN13(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		isbn := ISBN13()
+		test.Equals(t, 13, len(isbn))
+		test.Assert(t, hasAnyPrefix(isbn, []string{"978", "979"}))
+		test.Assert(t, validEAN(isbn))
+	}
+}
+
+func TestEAN13(t *testing.T) {
+	test.Equals(t, 1, ean("400638133393"))
+
+	for i := 0; i < 1000; i++ {
+		barcode := EAN13()
+		test.Equals(t, 13, len(barcode))
+		test.Assert(t, validEAN(barcode))
+	}
+}
+
+func TestRoutingNumber(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		number := RoutingNumber()
+		test.Equals(t, 9, len(number))
+		test.Assert(t, regexp.MustCompile(`^(0[1-9]|1[0-2]|2[1-9]|3[0-2]|6[1-9]|7[0-2]|80)`).MatchString(number))
+
+		var sum int
+		for j, c := range number {
+			sum += []int{3, 7, 1}[j%3] * int(c-'0')
+		}
+		test.Equals(t, 0, sum%10)
+	}
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func validLuhn(number string) bool {
+	var sum int
+	for i := range number {
+		d := int(number[len(number)-1-i] - '0')
+		if i%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+func validEAN(number string) bool {
+	var sum int
+	for i, c := range number {
+		sum += []int{1, 3}[i%2] * int(c-'0')
+	}
+	return sum%10 == 0
+}
+
+// validIBAN checks an IBAN with piece-wise mod-97 arithmetic, rather than
+// the big integers used to generate them.
+func validIBAN(iban string) bool {
+	var rem int
+	for _, c := range ibanDigits(iban[4:] + iban[:4]) {
+		rem = (rem*10 + int(c-'0')) % 97
+	}
+	return rem == 1
+}
+
+func bbanLength(format string) int {
+	var total int
+	for _, part := range regexp.MustCompile(`[0-9]+`).FindAllString(format, -1) {
+		n := 0
+		for _, c := range part {
+			n = n*10 + int(c-'0')
+		}
+		total += n
+	}
+	return total
+}
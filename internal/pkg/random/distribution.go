@@ -0,0 +1,115 @@
+package random
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// Normal returns a random float from a normal distribution with the
+// given mean and standard deviation, clamped between a minimum and
+// maximum.
+func Normal(mean, stddev, min, max float64) (float64, error) {
+	if stddev < 0 {
+		return 0, fmt.Errorf("standard deviation must not be negative, got %v", stddev)
+	}
+	if min > max {
+		min, max = max, min
+	}
+
+	return math.Max(min, math.Min(max, rand.NormFloat64()*stddev+mean)), nil
+}
+
+// LogNormal returns a random float from a log-normal distribution, whose
+// logarithm is normally distributed with the given mean (mu) and standard
+// deviation (sigma).
+func LogNormal(mu, sigma float64) (float64, error) {
+	if sigma < 0 {
+		return 0, fmt.Errorf("sigma must not be negative, got %v", sigma)
+	}
+
+	return math.Exp(rand.NormFloat64()*sigma + mu), nil
+}
+
+// Exponential returns a random float from an exponential distribution
+// with the given rate (the inverse of its mean), which is useful for the
+// time between independent events.
+func Exponential(rate float64) (float64, error) {
+	if rate <= 0 {
+		return 0, fmt.Errorf("rate must be positive, got %v", rate)
+	}
+
+	return rand.ExpFloat64() / rate, nil
+}
+
+// Poisson returns a random integer from a Poisson distribution with the
+// given mean (lambda), which is useful for the number of events in an
+// interval.
+func Poisson(lambda float64) (int64, error) {
+	if lambda < 0 {
+		return 0, fmt.Errorf("lambda must not be negative, got %v", lambda)
+	}
+
+	// Knuth's algorithm is exact but its running time grows with lambda,
+	// so larger means use a normal approximation.
+	if lambda > 30 {
+		return int64(math.Max(0, math.Round(rand.NormFloat64()*math.Sqrt(lambda)+lambda))), nil
+	}
+
+	l, k, p := math.Exp(-lambda), int64(0), 1.0
+	for {
+		p *= rand.Float64()
+		if p <= l {
+			return k, nil
+		}
+		k++
+	}
+}
+
+// Pareto returns a random float from a Pareto distribution with the
+// given scale (the minimum value) and shape, where smaller shapes give
+// longer tails.
+func Pareto(scale, shape float64) (float64, error) {
+	if scale <= 0 || shape <= 0 {
+		return 0, fmt.Errorf("scale and shape must be positive, got %v and %v", scale, shape)
+	}
+
+	return scale / math.Pow(1-rand.Float64(), 1/shape), nil
+}
+
+type zipfParams struct {
+	s   float64
+	max uint64
+}
+
+var (
+	zipfsMu sync.Mutex
+	zipfs   = map[zipfParams]*rand.Zipf{}
+)
+
+// Zipf returns a random integer between 1 and max from a Zipf
+// distribution with the given exponent (s), which must be greater than
+// 1.  Smaller integers are returned far more often than larger ones.
+func Zipf(s float64, max int64) (int64, error) {
+	if s <= 1 {
+		return 0, fmt.Errorf("exponent must be greater than 1, got %v", s)
+	}
+	if max < 1 {
+		return 0, fmt.Errorf("max must be at least 1, got %d", max)
+	}
+
+	zipfsMu.Lock()
+	defer zipfsMu.Unlock()
+
+	// Distributions are relatively expensive to create, so are kept for
+	// subsequent calls with the same parameters.
+	params := zipfParams{s: s, max: uint64(max)}
+	z, ok := zipfs[params]
+	if !ok {
+		z = rand.NewZipf(rand.New(rand.NewSource(rand.Int63())), s, 1, uint64(max-1))
+		zipfs[params] = z
+	}
+
+	return int64(z.Uint64()) + 1, nil
+}
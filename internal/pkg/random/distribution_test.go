@@ -0,0 +1,142 @@
+package random
+
+import (
+	"math"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+const samples = 100000
+
+// stats returns the mean and variance of n samples.
+func stats(t *testing.T, n int, sample func() (float64, error)) (mean, variance float64) {
+	t.Helper()
+
+	values := make([]float64, n)
+	for i := range values {
+		v, err := sample()
+		test.ErrorExists(t, false, err)
+		values[i] = v
+		mean += v
+	}
+	mean /= float64(n)
+
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(n - 1)
+
+	return mean, variance
+}
+
+// near asserts that act is within a relative tolerance of exp.
+func near(t *testing.T, exp, act, tolerance float64) {
+	t.Helper()
+	if math.Abs(act-exp) > math.Abs(exp)*tolerance {
+		t.Fatalf("exp %v (±%v%%) but got %v", exp, tolerance*100, act)
+	}
+}
+
+func TestNormal(t *testing.T) {
+	mean, variance := stats(t, samples, func() (float64, error) { return Normal(100, 15, math.Inf(-1), math.Inf(1)) })
+	near(t, 100, mean, 0.01)
+	near(t, 15*15, variance, 0.05)
+
+	for i := 0; i < samples; i++ {
+		v, err := Normal(100, 15, 90, 110)
+		test.ErrorExists(t, false, err)
+		test.Assert(t, v >= 90 && v <= 110)
+	}
+
+	_, err := Normal(100, -1, 0, 200)
+	test.ErrorExists(t, true, err)
+}
+
+func TestLogNormal(t *testing.T) {
+	mu, sigma := 1.0, 0.5
+
+	mean, variance := stats(t, samples, func() (float64, error) { return LogNormal(mu, sigma) })
+	near(t, math.Exp(mu+sigma*sigma/2), mean, 0.02)
+	near(t, (math.Exp(sigma*sigma)-1)*math.Exp(2*mu+sigma*sigma), variance, 0.1)
+
+	_, err := LogNormal(1, -1)
+	test.ErrorExists(t, true, err)
+}
+
+func TestExponential(t *testing.T) {
+	rate := 0.5
+
+	mean, variance := stats(t, samples, func() (float64, error) { return Exponential(rate) })
+	near(t, 1/rate, mean, 0.02)
+	near(t, 1/(rate*rate), variance, 0.05)
+
+	_, err := Exponential(0)
+	test.ErrorExists(t, true, err)
+}
+
+func TestPoisson(t *testing.T) {
+	cases := []struct {
+		name   string
+		lambda float64
+	}{
+		{name: "small lambda", lambda: 4},
+		{name: "large lambda", lambda: 100},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mean, variance := stats(t, samples, func() (float64, error) {
+				v, err := Poisson(c.lambda)
+				return float64(v), err
+			})
+
+			// The mean and variance of a Poisson distribution are both
+			// lambda.
+			near(t, c.lambda, mean, 0.02)
+			near(t, c.lambda, variance, 0.05)
+		})
+	}
+
+	_, err := Poisson(-1)
+	test.ErrorExists(t, true, err)
+}
+
+func TestPareto(t *testing.T) {
+	scale, shape := 2.0, 5.0
+
+	mean, variance := stats(t, samples, func() (float64, error) { return Pareto(scale, shape) })
+	near(t, shape*scale/(shape-1), mean, 0.02)
+	near(t, scale*scale*shape/((shape-1)*(shape-1)*(shape-2)), variance, 0.2)
+
+	for i := 0; i < samples; i++ {
+		v, err := Pareto(scale, shape)
+		test.ErrorExists(t, false, err)
+		test.Assert(t, v >= scale)
+	}
+
+	_, err := Pareto(0, 1)
+	test.ErrorExists(t, true, err)
+}
+
+func TestZipf(t *testing.T) {
+	s, max := 2.0, int64(100)
+
+	counts := make([]int, max+1)
+	for i := 0; i < samples; i++ {
+		v, err := Zipf(s, max)
+		test.ErrorExists(t, false, err)
+		test.Assert(t, v >= 1 && v <= max)
+		counts[v]++
+	}
+
+	// The frequency of each value is inversely proportional to its rank
+	// raised to the exponent, so 1 is returned 4 times as often as 2.
+	near(t, 4, float64(counts[1])/float64(counts[2]), 0.1)
+	near(t, 9, float64(counts[1])/float64(counts[3]), 0.15)
+
+	_, err := Zipf(1, max)
+	test.ErrorExists(t, true, err)
+	_, err = Zipf(s, 0)
+	test.ErrorExists(t, true, err)
+}
@@ -0,0 +1,210 @@
+package random
+
+import (
+	"math/rand"
+	"regexp/syntax"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// regexMaxRepeat is the maximum number of times unbounded repetitions
+// (*, + and {n,}) repeat beyond their minimum.
+const regexMaxRepeat = 10
+
+// printable holds the printable ASCII characters, which characters are
+// preferably chosen from when a character class is very large, such as
+// a negated class or ".".
+var printable = []rune{' ', '~'}
+
+// surrogates holds the UTF-16 surrogate halves, which aren't valid runes
+// on their own.
+var surrogates = []rune{0xD800, 0xDFFF}
+
+var (
+	regexesMu sync.Mutex
+	regexes   = map[string]*syntax.Regexp{}
+)
+
+// Regex returns a random string that matches a regular expression, in
+// the syntax accepted by Go's regexp package.
+func Regex(pattern string) (string, error) {
+	regexesMu.Lock()
+	re, ok := regexes[pattern]
+	if !ok {
+		var err error
+		if re, err = syntax.Parse(pattern, syntax.Perl); err != nil {
+			regexesMu.Unlock()
+			return "", errors.Wrap(err, "parsing pattern")
+		}
+		regexes[pattern] = re
+	}
+	regexesMu.Unlock()
+
+	b := strings.Builder{}
+	if err := generate(&b, re); err != nil {
+		return "", errors.Wrapf(err, "generating string for %q", pattern)
+	}
+	return b.String(), nil
+}
+
+func generate(b *strings.Builder, re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpNoMatch:
+		return errors.New("pattern can't match anything")
+	case syntax.OpEmptyMatch:
+		// Matches an empty string, so generates nothing.
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if re.Flags&syntax.FoldCase != 0 {
+				r = chooseFold(r)
+			}
+			b.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		r, err := chooseRune(re.Rune)
+		if err != nil {
+			return err
+		}
+		b.WriteRune(r)
+	case syntax.OpAnyCharNotNL, syntax.OpAnyChar:
+		r, err := chooseRune(printable)
+		if err != nil {
+			return err
+		}
+		b.WriteRune(r)
+	case syntax.OpCapture:
+		return generate(b, re.Sub[0])
+	case syntax.OpStar:
+		return repeat(b, re.Sub[0], 0, -1)
+	case syntax.OpPlus:
+		return repeat(b, re.Sub[0], 1, -1)
+	case syntax.OpQuest:
+		return repeat(b, re.Sub[0], 0, 1)
+	case syntax.OpRepeat:
+		return repeat(b, re.Sub[0], re.Min, re.Max)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if err := generate(b, sub); err != nil {
+				return err
+			}
+		}
+	case syntax.OpAlternate:
+		return generate(b, re.Sub[rand.Intn(len(re.Sub))])
+	}
+
+	// Other operations, such as anchors and word boundaries, match empty
+	// strings so generate nothing.
+	return nil
+}
+
+// chooseFold returns a random rune that's equivalent to r under case
+// folding, including r itself.  Some runes have more than one equivalent
+// (s, S and ſ, for example), so the whole orbit is collected.
+func chooseFold(r rune) rune {
+	orbit := []rune{r}
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		orbit = append(orbit, f)
+	}
+	return orbit[rand.Intn(len(orbit))]
+}
+
+// repeat generates a sub-expression between min and max times.  A max of
+// -1 means there's no upper bound.
+func repeat(b *strings.Builder, re *syntax.Regexp, min, max int) error {
+	if max < 0 {
+		max = min + regexMaxRepeat
+	}
+
+	for i := min + rand.Intn(max-min+1); i > 0; i-- {
+		if err := generate(b, re); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chooseRune returns a random rune from a character class, given as
+// pairs of inclusive ranges.  Printable ASCII characters are chosen from
+// large classes, where possible.  Surrogates are never chosen, as they
+// can't be encoded in UTF-8.
+func chooseRune(ranges []rune) (rune, error) {
+	ranges = exclude(ranges, surrogates)
+	if size(ranges) > 0xFFFF {
+		if ascii := intersect(ranges, printable); len(ascii) > 0 {
+			ranges = ascii
+		}
+	}
+
+	if size(ranges) == 0 {
+		return 0, errors.New("character class can't match anything")
+	}
+
+	n := rand.Intn(size(ranges))
+	for i := 0; i < len(ranges); i += 2 {
+		width := int(ranges[i+1]-ranges[i]) + 1
+		if n < width {
+			return ranges[i] + rune(n), nil
+		}
+		n -= width
+	}
+
+	panic("didn't choose a rune")
+}
+
+func size(ranges []rune) int {
+	var n int
+	for i := 0; i < len(ranges); i += 2 {
+		n += int(ranges[i+1]-ranges[i]) + 1
+	}
+	return n
+}
+
+// intersect returns the parts of ranges that fall within a single pair of
+// bounds.
+func intersect(ranges, bounds []rune) []rune {
+	lo, hi := bounds[0], bounds[1]
+
+	var output []rune
+	for i := 0; i < len(ranges); i += 2 {
+		from, to := ranges[i], ranges[i+1]
+		if from < lo {
+			from = lo
+		}
+		if to > hi {
+			to = hi
+		}
+		if from <= to {
+			output = append(output, from, to)
+		}
+	}
+	return output
+}
+
+// exclude returns the parts of ranges that fall outside a single pair of
+// bounds.
+func exclude(ranges, bounds []rune) []rune {
+	lo, hi := bounds[0], bounds[1]
+
+	var output []rune
+	for i := 0; i < len(ranges); i += 2 {
+		from, to := ranges[i], ranges[i+1]
+		if from < lo {
+			end := to
+			if end >= lo {
+				end = lo - 1
+			}
+			output = append(output, from, end)
+		}
+		if to > hi {
+			start := from
+			if start <= hi {
+				start = hi + 1
+			}
+			output = append(output, start, to)
+		}
+	}
+	return output
+}
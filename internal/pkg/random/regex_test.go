@@ -0,0 +1,113 @@
+package random
+
+import (
+	"regexp"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestRegex(t *testing.T) {
+	cases := []struct {
+		name     string
+		pattern  string
+		expError bool
+	}{
+		{name: "literal", pattern: `abc`},
+		{name: "identifier", pattern: `[A-Z]{3}-[0-9]{4}(-X)?`},
+		{name: "character classes", pattern: `\d\w\s[[:alpha:]][^a-z]\pL`},
+		{name: "any character", pattern: `a.b`},
+		{name: "quantifiers", pattern: `a*b+c?d{2}e{1,3}f{2,}`},
+		{name: "non-greedy quantifiers", pattern: `a*?b+?`},
+		{name: "alternation", pattern: `(cat|dog|fish)s?`},
+		{name: "nested groups", pattern: `((ab|cd)[0-9]){2}`},
+		{name: "anchors", pattern: `^\bfoo\b$`},
+		{name: "case insensitive", pattern: `(?i)hello`},
+		{name: "unicode", pattern: `[日本]{2}`},
+		{name: "email", pattern: `[a-z]{5,10}@[a-z]{5,10}\.(com|org|net)`},
+		{name: "empty match", pattern: `a(?:)b`},
+		{name: "invalid", pattern: `[a-`, expError: true},
+		{name: "no match", pattern: `a[^\x00-\x{10FFFF}]`, expError: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				s, err := Regex(c.pattern)
+				test.ErrorExists(t, c.expError, err)
+				if err != nil {
+					return
+				}
+
+				test.Assert(t, utf8.ValidString(s))
+				if !regexp.MustCompile(`^(?:` + c.pattern + `)$`).MatchString(s) {
+					t.Fatalf("%q doesn't match %q", s, c.pattern)
+				}
+			}
+		})
+	}
+}
+
+func TestRegexPrintable(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		s, err := Regex(`[^a-z].`)
+		test.ErrorExists(t, false, err)
+
+		for _, r := range s {
+			test.Assert(t, r >= ' ' && r <= '~')
+		}
+	}
+}
+
+func TestRegexFoldCase(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		s, err := Regex(`(?i)sk`)
+		test.ErrorExists(t, false, err)
+		seen[s] = true
+	}
+
+	test.Assert(t, seen["sk"])
+	test.Assert(t, seen["SK"])
+}
+
+func TestRegexRepeatBound(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		s, err := Regex(`a*`)
+		test.ErrorExists(t, false, err)
+		test.Assert(t, len(s) <= regexMaxRepeat)
+	}
+}
+
+func TestChooseRuneExcludesSurrogates(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		r, err := chooseRune([]rune{0xD800, 0x10FFFF})
+		test.ErrorExists(t, false, err)
+		test.Assert(t, r < 0xD800 || r > 0xDFFF)
+	}
+
+	_, err := chooseRune(surrogates)
+	test.ErrorExists(t, true, err)
+}
+
+func TestExclude(t *testing.T) {
+	cases := []struct {
+		name   string
+		ranges []rune
+		exp    []rune
+	}{
+		{name: "below", ranges: []rune{'a', 'z'}, exp: []rune{'a', 'z'}},
+		{name: "above", ranges: []rune{0xE000, 0xFFFF}, exp: []rune{0xE000, 0xFFFF}},
+		{name: "spanning", ranges: []rune{0, 0x10FFFF}, exp: []rune{0, 0xD7FF, 0xE000, 0x10FFFF}},
+		{name: "overlapping start", ranges: []rune{0xD000, 0xD900}, exp: []rune{0xD000, 0xD7FF}},
+		{name: "overlapping end", ranges: []rune{0xDF00, 0xE100}, exp: []rune{0xE000, 0xE100}},
+		{name: "within", ranges: []rune{0xD900, 0xDA00}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			test.Equals(t, c.exp, exclude(c.ranges, surrogates))
+		})
+	}
+}
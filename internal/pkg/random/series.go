@@ -0,0 +1,98 @@
+package random
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+
+	// mondayOffset is the time from the Unix epoch, a Thursday, to the
+	// start of the following Monday.
+	mondayOffset = 4 * day
+)
+
+// SeriesOptions configures a time series.
+type SeriesOptions struct {
+	// Start is the time of the first point.
+	Start time.Time
+
+	// Interval is the time between points.
+	Interval time.Duration
+
+	// Jitter is the maximum amount of time that each point's time is
+	// randomly moved by.  It must be less than half of the Interval, so
+	// times always increase.
+	Jitter time.Duration
+
+	// Base is the value of the series at the start.
+	Base float64
+
+	// Trend is the amount the value changes by each day.
+	Trend float64
+
+	// Daily and Weekly are the amplitudes of daily and weekly sine waves
+	// added to the value.  The daily wave peaks at 06:00 UTC, and the
+	// weekly wave starts on Monday, peaking at 18:00 UTC on Tuesday.
+	Daily  float64
+	Weekly float64
+
+	// Noise is the standard deviation of normally distributed noise
+	// added to the value.
+	Noise float64
+}
+
+// Series generates points of a time series, whose times increase at a
+// regular interval and whose values combine a trend, seasonality and
+// noise.
+type Series struct {
+	opts SeriesOptions
+	step int64
+}
+
+// NewSeries returns a pointer to a new Series.
+func NewSeries(opts SeriesOptions) (*Series, error) {
+	if opts.Interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive, got %s", opts.Interval)
+	}
+	if opts.Jitter < 0 || opts.Jitter*2 >= opts.Interval {
+		return nil, fmt.Errorf("jitter must be less than half of the interval, got %s", opts.Jitter)
+	}
+	if opts.Noise < 0 {
+		return nil, fmt.Errorf("noise must not be negative, got %v", opts.Noise)
+	}
+
+	return &Series{opts: opts}, nil
+}
+
+// Next returns the time and value of the next point in the series.
+func (s *Series) Next() (time.Time, float64) {
+	t := s.opts.Start.Add(time.Duration(s.step) * s.opts.Interval)
+	s.step++
+
+	if s.opts.Jitter > 0 {
+		t = t.Add(time.Duration(rand.Int63n(int64(s.opts.Jitter)*2+1)) - s.opts.Jitter)
+	}
+
+	return t, s.Value(t) + rand.NormFloat64()*s.opts.Noise
+}
+
+// Value returns the value of the series at a given time, without noise.
+func (s *Series) Value(t time.Time) float64 {
+	elapsed := t.Sub(s.opts.Start)
+
+	// Seasonality is relative to the start of the day and week, rather
+	// than the start of the series.
+	unix := t.UnixNano()
+	daily := math.Sin(2 * math.Pi * float64(unix%int64(day)) / float64(day))
+	weekly := math.Sin(2 * math.Pi * float64((unix-int64(mondayOffset))%int64(week)) / float64(week))
+
+	return s.opts.Base +
+		s.opts.Trend*elapsed.Hours()/24 +
+		s.opts.Daily*daily +
+		s.opts.Weekly*weekly
+}
@@ -0,0 +1,89 @@
+package random
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestNewSeries(t *testing.T) {
+	cases := []struct {
+		name     string
+		opts     SeriesOptions
+		expError bool
+	}{
+		{name: "valid", opts: SeriesOptions{Interval: time.Minute, Jitter: time.Second}},
+		{name: "missing interval", opts: SeriesOptions{}, expError: true},
+		{name: "jitter too large", opts: SeriesOptions{Interval: time.Minute, Jitter: 30 * time.Second}, expError: true},
+		{name: "negative noise", opts: SeriesOptions{Interval: time.Minute, Noise: -1}, expError: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := NewSeries(c.opts)
+			test.ErrorExists(t, c.expError, err)
+		})
+	}
+}
+
+func TestSeriesTimes(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	s, err := NewSeries(SeriesOptions{Start: start, Interval: time.Minute, Jitter: 29 * time.Second})
+	test.ErrorExists(t, false, err)
+
+	prev := start.Add(-time.Minute)
+	for i := 0; i < 10000; i++ {
+		ts, _ := s.Next()
+
+		exp := start.Add(time.Duration(i) * time.Minute)
+		test.Assert(t, ts.After(prev))
+		test.Assert(t, ts.Sub(exp) <= 29*time.Second && exp.Sub(ts) <= 29*time.Second)
+		prev = ts
+	}
+}
+
+func TestSeriesValues(t *testing.T) {
+	// Weekly seasonality starts on Monday.
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	test.Equals(t, time.Monday, start.Weekday())
+
+	cases := []struct {
+		name string
+		opts SeriesOptions
+		at   time.Time
+		exp  float64
+	}{
+		{name: "base", opts: SeriesOptions{Base: 50}, at: start.Add(time.Hour), exp: 50},
+		{name: "trend", opts: SeriesOptions{Base: 50, Trend: 2}, at: start.Add(36 * time.Hour), exp: 53},
+		{name: "daily peak", opts: SeriesOptions{Daily: 10}, at: start.Add(6 * time.Hour), exp: 10},
+		{name: "daily trough", opts: SeriesOptions{Daily: 10}, at: start.Add(18 * time.Hour), exp: -10},
+		{name: "weekly start", opts: SeriesOptions{Weekly: 10}, at: start.Add(3 * week), exp: 0},
+		{name: "weekly peak", opts: SeriesOptions{Weekly: 10}, at: start.Add(week / 4), exp: 10},
+		{name: "weekly trough", opts: SeriesOptions{Weekly: 10}, at: start.Add(week * 3 / 4), exp: -10},
+		{name: "weekly before epoch", opts: SeriesOptions{Weekly: 10}, at: time.Date(1969, time.December, 30, 18, 0, 0, 0, time.UTC), exp: 10},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.opts.Start, c.opts.Interval = start, time.Minute
+			s, err := NewSeries(c.opts)
+			test.ErrorExists(t, false, err)
+
+			test.Assert(t, math.Abs(s.Value(c.at)-c.exp) < 1e-9)
+		})
+	}
+}
+
+func TestSeriesNoise(t *testing.T) {
+	s, err := NewSeries(SeriesOptions{Interval: time.Minute, Base: 100, Noise: 5})
+	test.ErrorExists(t, false, err)
+
+	mean, variance := stats(t, samples, func() (float64, error) {
+		_, v := s.Next()
+		return v, nil
+	})
+	near(t, 100, mean, 0.01)
+	near(t, 25, variance, 0.05)
+}
@@ -0,0 +1,65 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+)
+
+// backend holds the rows kept for each group of a store.
+type backend interface {
+	append(group string, row map[string]interface{}) error
+	replace(group string, i int, row map[string]interface{}) error
+	get(group string, i int) (map[string]interface{}, error)
+	len(group string) int
+	groups() []string
+	close() error
+}
+
+// memoryBackend holds rows in memory.
+type memoryBackend struct {
+	data map[string][]map[string]interface{}
+}
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{data: map[string][]map[string]interface{}{}}
+}
+
+func (m *memoryBackend) append(group string, row map[string]interface{}) error {
+	m.data[group] = append(m.data[group], row)
+	return nil
+}
+
+func (m *memoryBackend) replace(group string, i int, row map[string]interface{}) error {
+	if i >= len(m.data[group]) {
+		return fmt.Errorf("data not found key=%q index=%d", group, i)
+	}
+
+	m.data[group][i] = row
+	return nil
+}
+
+func (m *memoryBackend) get(group string, i int) (map[string]interface{}, error) {
+	if i >= len(m.data[group]) {
+		return nil, fmt.Errorf("data not found key=%q index=%d", group, i)
+	}
+
+	return m.data[group][i], nil
+}
+
+func (m *memoryBackend) len(group string) int {
+	return len(m.data[group])
+}
+
+func (m *memoryBackend) groups() []string {
+	groups := make([]string, 0, len(m.data))
+	for group := range m.data {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	return groups
+}
+
+func (m *memoryBackend) close() error {
+	return nil
+}
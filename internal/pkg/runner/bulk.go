@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"context"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/pkg/errors"
+)
+
+// pendingRecords holds the records of a bulk-loading block that haven't
+// yet been written to the Sink.
+type pendingRecords struct {
+	// source identifies the block that the records came from.
+	source interface{}
+	table  string
+	rows   [][]interface{}
+	write  func(ctx context.Context, rows [][]interface{}) error
+}
+
+// copy buffers the records of a COPY block, writing them to the Sink in
+// batches.
+func (r *Runner) copy(ctx context.Context, b parse.Block) error {
+	return r.buffer(ctx, b, b.Copy, b.Copy.Table, func(ctx context.Context, rows [][]interface{}) error {
+		copier, ok := r.sink.(sink.Copier)
+		if !ok {
+			return errors.New("output does not support copying")
+		}
+		return copier.Copy(ctx, b.Copy.Table, b.Columns, rows)
+	})
+}
+
+// infile buffers the records of an INFILE block, writing them to the
+// Sink in batches.
+func (r *Runner) infile(ctx context.Context, b parse.Block) error {
+	opts := sink.InfileOptions{}
+	if f, ok := b.Infile.Options["fields"]; ok {
+		opts.FieldsTerminatedBy = unescape(f)
+	}
+	if l, ok := b.Infile.Options["lines"]; ok {
+		opts.LinesTerminatedBy = unescape(l)
+	}
+
+	return r.buffer(ctx, b, b.Infile, b.Infile.Table, func(ctx context.Context, rows [][]interface{}) error {
+		loader, ok := r.sink.(sink.Loader)
+		if !ok {
+			return errors.New("output does not support loading data")
+		}
+		return loader.LoadData(ctx, b.Infile.Table, b.Columns, rows, opts)
+	})
+}
+
+// buffer buffers the records collected during the execution of a
+// bulk-loading block, writing them in batches.  A batch size of zero or
+// less buffers all of a block's records until it's flushed.
+func (r *Runner) buffer(ctx context.Context, b parse.Block, source interface{}, table string, write func(context.Context, [][]interface{}) error) error {
+	if err := r.keepRecords(b); err != nil {
+		return err
+	}
+
+	// Records from a different block can't share a statement.
+	if r.pending.source != source {
+		if err := r.FlushContext(ctx); err != nil {
+			return err
+		}
+		r.pending = pendingRecords{source: source, table: table, write: write}
+	}
+
+	r.pending.rows = append(r.pending.rows, r.records...)
+	for r.batchSize > 0 && len(r.pending.rows) >= r.batchSize {
+		if err := r.flushRows(ctx, r.batchSize); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Flush writes any records buffered by bulk-loading blocks to the Sink.
+// It should be called once a block has finished repeating.
+func (r *Runner) Flush() error {
+	return r.FlushContext(context.Background())
+}
+
+// FlushContext writes any records buffered by bulk-loading blocks to the
+// Sink using the given context.
+func (r *Runner) FlushContext(ctx context.Context) error {
+	return r.flushRows(ctx, len(r.pending.rows))
+}
+
+func (r *Runner) flushRows(ctx context.Context, n int) error {
+	if n == 0 {
+		return nil
+	}
+
+	if err := r.pending.write(ctx, r.pending.rows[:n]); err != nil {
+		return errors.Wrapf(err, "loading into %q", r.pending.table)
+	}
+
+	r.pending.rows = r.pending.rows[n:]
+	if r.progress != nil {
+		r.progress(n)
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+// copySink records the batches of rows it's asked to copy.
+type copySink struct {
+	*sink.Writer
+	batches [][][]interface{}
+}
+
+func (s *copySink) Copy(_ context.Context, table string, columns []string, rows [][]interface{}) error {
+	s.batches = append(s.batches, rows)
+	return nil
+}
+
+func TestRunCopy(t *testing.T) {
+	cases := []struct {
+		name       string
+		batchSize  int
+		repeat     int
+		expBatches []int
+	}{
+		{name: "batch size larger than rows", batchSize: 100, repeat: 3, expBatches: []int{6}},
+		{name: "batch size equal to iteration", batchSize: 2, repeat: 3, expBatches: []int{2, 2, 2}},
+		{name: "batch size splitting iterations", batchSize: 4, repeat: 3, expBatches: []int{4, 2}},
+		{name: "unbatched", batchSize: 0, repeat: 3, expBatches: []int{6}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := &copySink{Writer: sink.NewWriter(&bytes.Buffer{})}
+
+			var progress int
+			r := mustNew(t, s, WithBatchSize(c.batchSize), WithProgress(func(rows int) { progress += rows }))
+
+			b := parse.Block{
+				Name:    "owner",
+				Body:    `{{range $i, $e := ntimes 2}}{{record $i (set "a")}}{{end}}`,
+				Columns: []string{"id", "name"},
+				Copy:    &parse.Copy{Table: "owner"},
+			}
+
+			for i := 0; i < c.repeat; i++ {
+				test.ErrorExists(t, false, r.Run(b))
+			}
+			test.ErrorExists(t, false, r.Flush())
+
+			batches := []int{}
+			for _, batch := range s.batches {
+				batches = append(batches, len(batch))
+			}
+			test.Equals(t, c.expBatches, batches)
+			test.Equals(t, c.repeat*2, progress)
+
+			// Records are available to later blocks.
+			name, err := r.store.reference("owner", "name")
+			test.ErrorExists(t, false, err)
+			test.Equals(t, "a", name)
+		})
+	}
+}
+
+func TestRunCopyFlushesBetweenBlocks(t *testing.T) {
+	s := &copySink{Writer: sink.NewWriter(&bytes.Buffer{})}
+	r := mustNew(t, s)
+
+	a := parse.Block{Body: `{{record 1}}`, Columns: []string{"id"}, Copy: &parse.Copy{Table: "a"}}
+	b := parse.Block{Body: `{{record 2}}`, Columns: []string{"id"}, Copy: &parse.Copy{Table: "b"}}
+
+	test.ErrorExists(t, false, r.Run(a))
+	test.ErrorExists(t, false, r.Run(b))
+	test.ErrorExists(t, false, r.Close())
+
+	test.Equals(t, [][][]interface{}{{{1}}, {{2}}}, s.batches)
+}
+
+func TestRunCopyUnsupportedSink(t *testing.T) {
+	r := mustNew(t, sink.NewDatabase(db))
+	r.sink = struct{ sink.Sink }{r.sink}
+
+	b := parse.Block{Body: `{{record 1}}`, Columns: []string{"id"}, Copy: &parse.Copy{Table: "a"}}
+
+	test.ErrorExists(t, false, r.Run(b))
+	test.ErrorExists(t, true, r.Flush())
+}
+
+// loadSink records the options and rows it's asked to load.
+type loadSink struct {
+	*sink.Writer
+	opts sink.InfileOptions
+	rows [][]interface{}
+}
+
+func (s *loadSink) LoadData(_ context.Context, table string, columns []string, rows [][]interface{}, opts sink.InfileOptions) error {
+	s.opts = opts
+	s.rows = append(s.rows, rows...)
+	return nil
+}
+
+func TestRunInfile(t *testing.T) {
+	s := &loadSink{Writer: sink.NewWriter(&bytes.Buffer{})}
+	r := mustNew(t, s)
+
+	b := parse.Block{
+		Name:    "pet",
+		Body:    `{{range $i, $e := ntimes 2}}{{record $i (set "a")}}{{end}}`,
+		Columns: []string{"pid", "name"},
+		Infile: &parse.Infile{
+			Table:   "pet",
+			Options: map[string]string{"fields": ",", "lines": `\r\n`},
+		},
+	}
+
+	test.ErrorExists(t, false, r.Run(b))
+	test.ErrorExists(t, false, r.Flush())
+
+	test.Equals(t, sink.InfileOptions{FieldsTerminatedBy: ",", LinesTerminatedBy: "\r\n"}, s.opts)
+	test.Equals(t, [][]interface{}{{0, "a"}, {1, "a"}}, s.rows)
+}
+
+func TestRunInfileWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := mustNew(t, sink.NewWriter(buf))
+
+	b := parse.Block{Body: `{{record 1 (set "a")}}`, Columns: []string{"id", "name"}, Infile: &parse.Infile{Table: "pet"}}
+
+	test.ErrorExists(t, false, r.Run(b))
+	test.ErrorExists(t, false, r.Flush())
+	test.ErrorExists(t, false, r.Close())
+	test.Equals(t, "INSERT INTO `pet` (`id`, `name`) VALUES\n(1, 'a');\n", buf.String())
+}
+
+func TestRunInfileUnsupportedSink(t *testing.T) {
+	r := mustNew(t, sink.NewDatabase(db))
+	r.sink = struct{ sink.Sink }{r.sink}
+
+	b := parse.Block{Body: `{{record 1}}`, Columns: []string{"id"}, Infile: &parse.Infile{Table: "a"}}
+
+	test.ErrorExists(t, false, r.Run(b))
+	test.ErrorExists(t, true, r.Flush())
+}
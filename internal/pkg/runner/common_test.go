@@ -3,8 +3,10 @@ package runner
 import (
 	"database/sql"
 	"log"
+	"testing"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
 )
 
 var (
@@ -18,3 +20,13 @@ func resetMock() {
 		log.Fatalf("error creating sqlmock: %v", err)
 	}
 }
+
+func mustNew(tb testing.TB, s sink.Sink, opts ...Option) *Runner {
+	tb.Helper()
+
+	r, err := New(s, opts...)
+	if err != nil {
+		tb.Fatalf("error creating runner: %v", err)
+	}
+	return r
+}
@@ -0,0 +1,398 @@
+package runner
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// diskBatchSize is the number of rows written to disk in each
+// transaction.
+const diskBatchSize = 10000
+
+// diskBackend holds rows in a temporary bbolt file, allowing far more
+// rows to be kept than would fit in memory.  Each group is a bucket of
+// encoded rows, keyed by their index.
+type diskBackend struct {
+	db     *bolt.DB
+	path   string
+	counts map[string]int
+
+	// Writes are buffered and committed in batches, as committing each
+	// row individually is prohibitively slow.  Buffered rows are read
+	// from here, so that reads never need to write to the file, and can
+	// safely happen concurrently.
+	pending map[diskRow][]byte
+}
+
+type diskRow struct {
+	group string
+	index int
+}
+
+// newDiskBackend creates a temporary file in dir (or the default
+// directory for temporary files if dir is empty) to hold rows in.  The
+// file is removed when the backend is closed.
+func newDiskBackend(dir string) (*diskBackend, error) {
+	f, err := os.CreateTemp(dir, "datagen-*.db")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating store file")
+	}
+	path := f.Name()
+	if err = f.Close(); err != nil {
+		return nil, errors.Wrap(err, "creating store file")
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{NoSync: true, NoFreelistSync: true})
+	if err != nil {
+		os.Remove(path)
+		return nil, errors.Wrap(err, "opening store file")
+	}
+
+	return &diskBackend{
+		db:      db,
+		path:    path,
+		counts:  map[string]int{},
+		pending: map[diskRow][]byte{},
+	}, nil
+}
+
+func (d *diskBackend) append(group string, row map[string]interface{}) error {
+	if err := d.write(group, d.counts[group], row); err != nil {
+		return err
+	}
+
+	d.counts[group]++
+	return nil
+}
+
+func (d *diskBackend) replace(group string, i int, row map[string]interface{}) error {
+	if i >= d.counts[group] {
+		return fmt.Errorf("data not found key=%q index=%d", group, i)
+	}
+
+	return d.write(group, i, row)
+}
+
+func (d *diskBackend) write(group string, i int, row map[string]interface{}) error {
+	b, err := encodeRow(row)
+	if err != nil {
+		return errors.Wrapf(err, "encoding row for %q", group)
+	}
+
+	d.pending[diskRow{group: group, index: i}] = b
+	if len(d.pending) >= diskBatchSize {
+		return d.flush()
+	}
+	return nil
+}
+
+// flush commits any buffered writes.
+func (d *diskBackend) flush() error {
+	if len(d.pending) == 0 {
+		return nil
+	}
+
+	err := d.db.Update(func(tx *bolt.Tx) error {
+		for r, b := range d.pending {
+			bucket, err := tx.CreateBucketIfNotExists([]byte(r.group))
+			if err != nil {
+				return err
+			}
+			if err = bucket.Put(diskKey(r.index), b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "writing rows to store file")
+	}
+
+	d.pending = map[diskRow][]byte{}
+	return nil
+}
+
+func (d *diskBackend) get(group string, i int) (map[string]interface{}, error) {
+	if i >= d.counts[group] {
+		return nil, fmt.Errorf("data not found key=%q index=%d", group, i)
+	}
+
+	var row map[string]interface{}
+	var err error
+	if b, ok := d.pending[diskRow{group: group, index: i}]; ok {
+		row, err = decodeRow(b)
+	} else {
+		err = d.db.View(func(tx *bolt.Tx) error {
+			var err error
+			row, err = decodeRow(tx.Bucket([]byte(group)).Get(diskKey(i)))
+			return err
+		})
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading row %d for %q", i, group)
+	}
+
+	return row, nil
+}
+
+func (d *diskBackend) len(group string) int {
+	return d.counts[group]
+}
+
+func (d *diskBackend) groups() []string {
+	groups := make([]string, 0, len(d.counts))
+	for group := range d.counts {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	return groups
+}
+
+func (d *diskBackend) close() error {
+	if err := d.db.Close(); err != nil {
+		return errors.Wrap(err, "closing store file")
+	}
+
+	return errors.Wrap(os.Remove(d.path), "removing store file")
+}
+
+func diskKey(i int) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(i))
+	return key
+}
+
+// Type tags for encoded values.
+const (
+	tagNil byte = iota
+	tagString
+	tagBytes
+	tagInt
+	tagInt64
+	tagFloat64
+	tagBool
+	tagTime
+	tagUint64
+	tagFloat32
+)
+
+// encodeRow encodes the column names and values of a row.  Values are
+// limited to the types returned by database drivers and generators.
+func encodeRow(row map[string]interface{}) ([]byte, error) {
+	b := binary.AppendUvarint(nil, uint64(len(row)))
+
+	for column, v := range row {
+		b = appendBytes(b, []byte(column))
+
+		v, err := normalise(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting value for column %q", column)
+		}
+
+		switch v := v.(type) {
+		case nil:
+			b = append(b, tagNil)
+		case string:
+			b = appendBytes(append(b, tagString), []byte(v))
+		case []byte:
+			b = appendBytes(append(b, tagBytes), v)
+		case int:
+			b = binary.AppendVarint(append(b, tagInt), int64(v))
+		case int64:
+			b = binary.AppendVarint(append(b, tagInt64), v)
+		case uint64:
+			b = binary.AppendUvarint(append(b, tagUint64), v)
+		case float32:
+			b = binary.BigEndian.AppendUint32(append(b, tagFloat32), math.Float32bits(v))
+		case float64:
+			b = binary.BigEndian.AppendUint64(append(b, tagFloat64), math.Float64bits(v))
+		case bool:
+			if v {
+				b = append(b, tagBool, 1)
+			} else {
+				b = append(b, tagBool, 0)
+			}
+		case time.Time:
+			t, err := v.MarshalBinary()
+			if err != nil {
+				return nil, err
+			}
+			b = appendBytes(append(b, tagTime), t)
+		default:
+			return nil, fmt.Errorf("unsupported type %T for column %q", v, column)
+		}
+	}
+
+	return b, nil
+}
+
+// normalise converts the values that drivers scan into, such as
+// sql.NullInt64, int32 and sql.RawBytes, into one of the types that
+// encodeRow supports.
+func normalise(v interface{}) (interface{}, error) {
+	v = unwrap(v)
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		if v, err = valuer.Value(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch v.(type) {
+	case nil, string, []byte, int, int64, float32, float64, bool, time.Time:
+		return v, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), nil
+	case reflect.Float32:
+		return float32(rv.Float()), nil
+	case reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			// Drivers reuse the memory behind sql.RawBytes.
+			return append([]byte{}, rv.Bytes()...), nil
+		}
+	}
+
+	return v, nil
+}
+
+func appendBytes(b, v []byte) []byte {
+	return append(binary.AppendUvarint(b, uint64(len(v))), v...)
+}
+
+// decodeRow decodes a row encoded by encodeRow.
+func decodeRow(b []byte) (map[string]interface{}, error) {
+	d := decoder{b: b}
+
+	n := int(d.uvarint())
+	row := make(map[string]interface{}, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		column := string(d.bytes())
+
+		switch tag := d.byte(); tag {
+		case tagNil:
+			row[column] = nil
+		case tagString:
+			row[column] = string(d.bytes())
+		case tagBytes:
+			row[column] = append([]byte{}, d.bytes()...)
+		case tagInt:
+			row[column] = int(d.varint())
+		case tagInt64:
+			row[column] = d.varint()
+		case tagUint64:
+			row[column] = d.uvarint()
+		case tagFloat32:
+			row[column] = math.Float32frombits(d.uint32())
+		case tagFloat64:
+			row[column] = math.Float64frombits(d.uint64())
+		case tagBool:
+			row[column] = d.byte() == 1
+		case tagTime:
+			var t time.Time
+			if err := t.UnmarshalBinary(d.bytes()); err != nil {
+				return nil, err
+			}
+			row[column] = t
+		default:
+			return nil, fmt.Errorf("unknown type tag %d", tag)
+		}
+	}
+
+	return row, d.err
+}
+
+// decoder reads values from an encoded row, recording the first error
+// encountered.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.b) < n {
+		d.err = errors.New("unexpected end of row")
+		return nil
+	}
+
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) byte() byte {
+	if v := d.next(1); v != nil {
+		return v[0]
+	}
+	return 0
+}
+
+func (d *decoder) uint32() uint32 {
+	if v := d.next(4); v != nil {
+		return binary.BigEndian.Uint32(v)
+	}
+	return 0
+}
+
+func (d *decoder) uint64() uint64 {
+	if v := d.next(8); v != nil {
+		return binary.BigEndian.Uint64(v)
+	}
+	return 0
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		d.err = errors.New("invalid length")
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+
+	v, n := binary.Varint(d.b)
+	if n <= 0 {
+		d.err = errors.New("invalid integer")
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) bytes() []byte {
+	return d.next(int(d.uvarint()))
+}
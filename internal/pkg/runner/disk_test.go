@@ -0,0 +1,196 @@
+package runner
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestEncodeRow(t *testing.T) {
+	cases := []struct {
+		name     string
+		row      map[string]interface{}
+		expError bool
+	}{
+		{name: "empty", row: map[string]interface{}{}},
+		{
+			name: "supported types",
+			row: map[string]interface{}{
+				"nil":     nil,
+				"string":  "Alice",
+				"bytes":   []byte{0, 1, 2},
+				"int":     -123,
+				"int64":   int64(1) << 60,
+				"float64": 1.5,
+				"bool":    true,
+				"time":    time.Date(2019, time.January, 2, 3, 4, 5, 6, time.UTC),
+			},
+		},
+		{name: "unsupported type", row: map[string]interface{}{"id": struct{}{}}, expError: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, err := encodeRow(c.row)
+			test.ErrorExists(t, c.expError, err)
+			if err != nil {
+				return
+			}
+
+			act, err := decodeRow(b)
+			test.ErrorExists(t, false, err)
+			test.Equals(t, c.row, act)
+		})
+	}
+}
+
+func TestEncodeRowDriverTypes(t *testing.T) {
+	now := time.Date(2019, time.January, 2, 3, 4, 5, 0, time.UTC)
+
+	cases := []struct {
+		name  string
+		value interface{}
+		exp   interface{}
+	}{
+		{name: "int16", value: int16(-12), exp: int64(-12)},
+		{name: "int32", value: int32(1) << 30, exp: int64(1) << 30},
+		{name: "uint8", value: uint8(255), exp: uint64(255)},
+		{name: "uint64", value: uint64(1) << 63, exp: uint64(1) << 63},
+		{name: "float32", value: float32(0.1), exp: float32(0.1)},
+		{name: "raw bytes", value: sql.RawBytes("Alice"), exp: []byte("Alice")},
+		{name: "json number", value: json.Number("1.5"), exp: "1.5"},
+		{name: "valid null int64", value: sql.NullInt64{Int64: 1, Valid: true}, exp: int64(1)},
+		{name: "invalid null int64", value: sql.NullInt64{}, exp: nil},
+		{name: "valid null string", value: sql.NullString{String: "Alice", Valid: true}, exp: "Alice"},
+		{name: "invalid null string", value: sql.NullString{}, exp: nil},
+		{name: "valid null time", value: sql.NullTime{Time: now, Valid: true}, exp: now},
+		{name: "valid mysql null time", value: mysql.NullTime{Time: now, Valid: true}, exp: now},
+		{name: "invalid mysql null time", value: mysql.NullTime{}, exp: nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			b, err := encodeRow(map[string]interface{}{"v": c.value})
+			test.ErrorExists(t, false, err)
+
+			act, err := decodeRow(b)
+			test.ErrorExists(t, false, err)
+			test.Equals(t, map[string]interface{}{"v": c.exp}, act)
+		})
+	}
+}
+
+func TestDecodeRowTruncated(t *testing.T) {
+	b, err := encodeRow(map[string]interface{}{"name": "Alice", "age": 1.5})
+	test.ErrorExists(t, false, err)
+
+	for i := range b {
+		_, err = decodeRow(b[:i])
+		test.ErrorExists(t, true, err)
+	}
+}
+
+func TestDiskBackend(t *testing.T) {
+	dir := t.TempDir()
+	d, err := newDiskBackend(dir)
+	test.ErrorExists(t, false, err)
+
+	// Write enough rows to span several batches.
+	const rows = diskBatchSize*2 + 10
+	for i := 0; i < rows; i++ {
+		test.ErrorExists(t, false, d.append("owner", map[string]interface{}{"id": i}))
+	}
+	test.ErrorExists(t, false, d.append("pet", map[string]interface{}{"id": "a"}))
+	test.ErrorExists(t, false, d.replace("owner", 5, map[string]interface{}{"id": -5}))
+
+	test.Equals(t, rows, d.len("owner"))
+	test.Equals(t, 0, d.len("missing"))
+	test.Equals(t, []string{"owner", "pet"}, d.groups())
+
+	for _, i := range []int{0, diskBatchSize, rows - 1} {
+		row, err := d.get("owner", i)
+		test.ErrorExists(t, false, err)
+		test.Equals(t, map[string]interface{}{"id": i}, row)
+	}
+
+	row, err := d.get("owner", 5)
+	test.ErrorExists(t, false, err)
+	test.Equals(t, map[string]interface{}{"id": -5}, row)
+
+	_, err = d.get("owner", rows)
+	test.ErrorExists(t, true, err)
+	test.ErrorExists(t, true, d.replace("pet", 1, map[string]interface{}{}))
+
+	test.ErrorExists(t, false, d.close())
+
+	files, err := os.ReadDir(dir)
+	test.ErrorExists(t, false, err)
+	test.Equals(t, 0, len(files))
+}
+
+func TestDiskStoreConcurrentReads(t *testing.T) {
+	d, err := newDiskBackend(t.TempDir())
+	test.ErrorExists(t, false, err)
+	s := newStoreWithBackend(d)
+	defer s.close()
+
+	// Leave rows buffered, so that reads would previously have flushed.
+	for i := 0; i < 10; i++ {
+		test.ErrorExists(t, false, s.set("owner", map[string]interface{}{"id": i}))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := s.reference("owner", "id"); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	test.Equals(t, 10, len(d.pending))
+}
+
+func TestRunDiskStore(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := mustNew(t, sink.NewWriter(buf), WithDiskStore(t.TempDir()))
+
+	blocks := []parse.Block{
+		{
+			Name:    "owner",
+			Returns: []parse.Column{{Name: "id", Type: "int"}},
+			Body:    `insert into "owner" ("name") values ('a'), ('b'), ('c')`,
+		},
+		{
+			Name: "pet",
+			Body: `insert into "pet" ("pid") values ({{each "owner" "id" 0}}), ({{each "owner" "id" 1}}), ({{ref "owner" "id"}})`,
+		},
+	}
+
+	for _, b := range blocks {
+		r.ResetEach(b.Name)
+		test.ErrorExists(t, false, r.Run(b))
+	}
+
+	results, err := r.Results()
+	test.ErrorExists(t, false, err)
+	test.Equals(t, 3, len(results["owner"]))
+	test.Equals(t, int64(2), results["owner"][1]["id"])
+
+	test.ErrorExists(t, false, r.Close())
+	test.Assert(t, bytes.Contains(buf.Bytes(), []byte(`values (1), (2), (`)))
+}
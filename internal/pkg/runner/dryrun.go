@@ -0,0 +1,332 @@
+package runner
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/random"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// simulate stores the rows that a statement would have returned from
+// the database, for Sinks that don't return rows.  The columns come from
+// the block's RETURNS comment if it has one, or the statement's
+// RETURNING clause otherwise.  A row is simulated for each tuple in the
+// statement's VALUES clause, taking values from the tuples where
+// possible and generating the rest.
+func (r *Runner) simulate(b parse.Block, stmt string) error {
+	ins := parseInsert(stmt)
+
+	columns := b.Returns
+	if columns == nil {
+		for _, name := range ins.returning {
+			columns = append(columns, parse.Column{Name: name})
+		}
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	count := len(ins.tuples)
+	if count == 0 {
+		count = 1
+	}
+
+	return r.simulateRows(b.Name, columns, ins, count)
+}
+
+// simulateRows stores count simulated rows under the given name.
+func (r *Runner) simulateRows(name string, columns []parse.Column, ins insert, count int) error {
+	for i := 0; i < count; i++ {
+		curr := map[string]interface{}{}
+		for _, c := range columns {
+			if v, ok := ins.value(i, c.Name); ok {
+				curr[c.Name] = v
+				continue
+			}
+
+			v, err := r.simulateValue(name, c)
+			if err != nil {
+				return err
+			}
+			curr[c.Name] = v
+		}
+		if err := r.store.set(name, curr); err != nil {
+			return errors.Wrap(err, "storing row")
+		}
+	}
+
+	return nil
+}
+
+// simulateValue generates a value for a column that wasn't inserted
+// explicitly.  Columns without a type are assumed to be generated IDs.
+func (r *Runner) simulateValue(block string, c parse.Column) (interface{}, error) {
+	switch c.Type {
+	case "", "uuid":
+		return uuid.New().String(), nil
+	case "int", "serial":
+		key := block + "." + c.Name
+		r.serials[key]++
+		return r.serials[key], nil
+	case "string", "text":
+		return random.String(10, 10, ""), nil
+	case "float":
+		return random.Float(0, 1000), nil
+	case "date", "timestamp":
+		return time.Now().Format(r.dateFormat), nil
+	case "bool":
+		return rand.Intn(2) == 1, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %q for column %q", c.Type, c.Name)
+	}
+}
+
+// insert holds the parts of an INSERT statement needed to simulate the
+// rows it returns.
+type insert struct {
+	columns   []string
+	tuples    [][]string
+	returning []string
+}
+
+// value returns the literal value inserted into a column by a tuple.
+func (ins insert) value(tuple int, column string) (interface{}, bool) {
+	if tuple >= len(ins.tuples) {
+		return nil, false
+	}
+
+	for i, c := range ins.columns {
+		if strings.EqualFold(c, column) && i < len(ins.tuples[tuple]) {
+			return literal(ins.tuples[tuple][i]), true
+		}
+	}
+	return nil, false
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenIdent
+	tokenPunct
+)
+
+type token struct {
+	kind       tokenKind
+	text       string
+	start, end int
+}
+
+// parseInsert extracts the column list, VALUES tuples and RETURNING
+// columns of a statement.  Anything it doesn't understand is skipped,
+// so statements other than INSERTs simply produce no tuples.
+func parseInsert(stmt string) insert {
+	const (
+		sectionInto = iota
+		sectionValues
+		sectionOther
+	)
+
+	var ins insert
+	tokens := tokenize(stmt)
+	section, depth, start := sectionInto, 0, 0
+
+	for i, t := range tokens {
+		if t.kind == tokenPunct {
+			switch t.text {
+			case "(":
+				if depth++; depth == 1 {
+					start = i
+				}
+			case ")":
+				if depth--; depth != 0 {
+					continue
+				}
+
+				group := tokens[start+1 : i]
+				switch section {
+				case sectionInto:
+					ins.columns = ins.columns[:0]
+					for _, expr := range splitTokens(group) {
+						ins.columns = append(ins.columns, columnName(expr))
+					}
+				case sectionValues:
+					tuple := []string{}
+					for _, expr := range splitTokens(group) {
+						tuple = append(tuple, stmt[expr[0].start:expr[len(expr)-1].end])
+					}
+					ins.tuples = append(ins.tuples, tuple)
+				}
+			case ";":
+				if depth == 0 {
+					return ins
+				}
+			}
+			continue
+		}
+
+		if depth != 0 || t.kind != tokenWord {
+			continue
+		}
+
+		switch strings.ToLower(t.text) {
+		case "values":
+			if section == sectionInto {
+				section = sectionValues
+			}
+		case "returning":
+			ins.returning = returningColumns(tokens[i+1:], ins.columns)
+			return ins
+		case "select":
+			section = sectionOther
+		default:
+			if section == sectionValues {
+				section = sectionOther
+			}
+		}
+	}
+
+	return ins
+}
+
+// returningColumns returns the names of the columns in a RETURNING
+// clause, expanding "*" to the inserted columns.
+func returningColumns(tokens []token, inserted []string) []string {
+	var end int
+	for end < len(tokens) && tokens[end].text != ";" {
+		end++
+	}
+
+	columns := []string{}
+	for _, expr := range splitTokens(tokens[:end]) {
+		if len(expr) == 1 && expr[0].text == "*" {
+			columns = append(columns, inserted...)
+			continue
+		}
+		columns = append(columns, columnName(expr))
+	}
+	return columns
+}
+
+// columnName returns the name of a column expression, which is the
+// last identifier in it, allowing for aliases and qualified names.
+func columnName(expr []token) string {
+	name := expr[len(expr)-1].text
+	if i := strings.LastIndex(name, "."); i >= 0 && expr[len(expr)-1].kind == tokenWord {
+		name = name[i+1:]
+	}
+	return strings.Trim(name, "\"`")
+}
+
+// splitTokens splits tokens on the commas that aren't nested within
+// parentheses, omitting empty expressions.
+func splitTokens(tokens []token) [][]token {
+	var output [][]token
+	depth, start := 0, 0
+
+	for i := 0; i <= len(tokens); i++ {
+		if i < len(tokens) {
+			switch {
+			case tokens[i].text == "(" && tokens[i].kind == tokenPunct:
+				depth++
+				continue
+			case tokens[i].text == ")" && tokens[i].kind == tokenPunct:
+				depth--
+				continue
+			case tokens[i].text != "," || tokens[i].kind != tokenPunct || depth != 0:
+				continue
+			}
+		}
+
+		if i > start {
+			output = append(output, tokens[start:i])
+		}
+		start = i + 1
+	}
+
+	return output
+}
+
+// tokenize splits a statement into words, quoted strings, quoted
+// identifiers and punctuation.
+func tokenize(stmt string) []token {
+	var tokens []token
+
+	for i := 0; i < len(stmt); {
+		c := stmt[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '\'' || c == '"' || c == '`':
+			j := i + 1
+			for ; j < len(stmt); j++ {
+				if stmt[j] != c {
+					continue
+				}
+				// Doubled quotes are escaped quotes.
+				if j+1 < len(stmt) && stmt[j+1] == c {
+					j++
+					continue
+				}
+				break
+			}
+
+			end := j + 1
+			if end > len(stmt) {
+				end = len(stmt)
+			}
+
+			kind := tokenIdent
+			if c == '\'' {
+				kind = tokenString
+			}
+			tokens = append(tokens, token{kind: kind, text: stmt[i:end], start: i, end: end})
+			i = end
+		case isWordChar(c):
+			j := i
+			for j < len(stmt) && isWordChar(stmt[j]) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: stmt[i:j], start: i, end: j})
+			i = j
+		default:
+			tokens = append(tokens, token{kind: tokenPunct, text: string(c), start: i, end: i + 1})
+			i++
+		}
+	}
+
+	return tokens
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '.' || c == '$' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+}
+
+// literal converts the text of a SQL expression into the value it
+// represents, leaving anything that isn't a simple literal as text.
+func literal(expr string) interface{} {
+	if strings.EqualFold(expr, "null") {
+		return nil
+	}
+
+	if len(expr) >= 2 && expr[0] == '\'' && expr[len(expr)-1] == '\'' {
+		return strings.Replace(expr[1:len(expr)-1], "''", "'", -1)
+	}
+
+	if i, err := strconv.ParseInt(expr, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(expr, 64); err == nil {
+		return f
+	}
+
+	return expr
+}
@@ -0,0 +1,119 @@
+package runner
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestParseInsert(t *testing.T) {
+	cases := []struct {
+		name string
+		stmt string
+		exp  insert
+	}{
+		{
+			name: "multi-row insert with returning",
+			stmt: `insert into "owner" ("email", "age") values('a@b.com',1),('it''s, (not) a tuple',NULL)returning "id", "email";`,
+			exp: insert{
+				columns:   []string{"email", "age"},
+				tuples:    [][]string{{"'a@b.com'", "1"}, {"'it''s, (not) a tuple'", "NULL"}},
+				returning: []string{"id", "email"},
+			},
+		},
+		{
+			name: "function calls and aliases",
+			stmt: "INSERT INTO public.pet (name, born) VALUES (upper('x'), now() - interval '1 day') RETURNING pet.id, name AS pet_name",
+			exp: insert{
+				columns:   []string{"name", "born"},
+				tuples:    [][]string{{"upper('x')", "now() - interval '1 day'"}},
+				returning: []string{"id", "pet_name"},
+			},
+		},
+		{
+			name: "on conflict isn't a tuple",
+			stmt: `insert into t (a) values (1) on conflict (a) do nothing returning *`,
+			exp: insert{
+				columns:   []string{"a"},
+				tuples:    [][]string{{"1"}},
+				returning: []string{"a"},
+			},
+		},
+		{
+			name: "insert select",
+			stmt: `insert into t (a) select coalesce(b, 1) from u returning id`,
+			exp: insert{
+				columns:   []string{"a"},
+				returning: []string{"id"},
+			},
+		},
+		{
+			name: "not an insert",
+			stmt: `select 1`,
+			exp:  insert{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			test.Equals(t, c.exp, parseInsert(c.stmt))
+		})
+	}
+}
+
+func TestRunSimulated(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := mustNew(t, sink.NewWriter(buf), WithDateFormat("2006-01-02"))
+
+	blocks := []parse.Block{
+		{
+			Name: "owner",
+			Body: `insert into "owner" ("email") values ('a@b.com'),('c@d.com') returning "id", "email"`,
+		},
+		{
+			Name:    "account",
+			Returns: []parse.Column{{Name: "id", Type: "int"}, {Name: "opened", Type: "date"}},
+			Body:    `insert into "account" ("owner_id") values ('{{ref "owner" "id"}}')`,
+		},
+		{
+			Name: "pet",
+			Body: `insert into "pet" ("pid", "aid", "email") values ('{{each "owner" "id" 1}}', {{ref "account" "id"}}, '{{row "owner" "email" 1}}')`,
+		},
+	}
+
+	for _, b := range blocks {
+		test.ErrorExists(t, false, r.Run(b))
+	}
+	test.ErrorExists(t, false, r.Close())
+
+	results, err := r.Results()
+	test.ErrorExists(t, false, err)
+	test.Equals(t, 2, len(results["owner"]))
+	test.Equals(t, "a@b.com", results["owner"][0]["email"])
+	test.Equals(t, "c@d.com", results["owner"][1]["email"])
+	test.Equals(t, 36, len(results["owner"][0]["id"].(string)))
+	test.Equals(t, int64(1), results["account"][0]["id"])
+	test.Equals(t, 0, len(results["pet"]))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	test.Equals(t, 3, len(lines))
+	test.Assert(t, strings.Contains(lines[1], results["owner"][0]["id"].(string)) ||
+		strings.Contains(lines[1], results["owner"][1]["id"].(string)))
+	test.Assert(t, strings.Contains(lines[2], ", 1, '"))
+}
+
+func TestRunSimulatedUnsupportedType(t *testing.T) {
+	r := mustNew(t, sink.NewWriter(&bytes.Buffer{}))
+
+	b := parse.Block{
+		Name:    "owner",
+		Returns: []parse.Column{{Name: "id", Type: "geometry"}},
+		Body:    `insert into "owner" ("email") values ('a@b.com')`,
+	}
+
+	test.ErrorExists(t, true, r.Run(b))
+}
@@ -2,10 +2,33 @@ package runner
 
 import (
 	"io/ioutil"
+	"reflect"
 )
 
 func (r *Runner) mustDumpQuery(stmt []byte) {
+	if r.queryErrFile == "" {
+		return
+	}
+
 	if err := ioutil.WriteFile(r.queryErrFile, stmt, 0644); err != nil {
 		panic(err)
 	}
 }
+
+// unwrap returns the underlying value of values scanned out of the
+// database, which are held as reflect.Values, and of Nullables, which
+// need unwrapping before they can be handed to a driver or the caller.
+func unwrap(v interface{}) interface{} {
+	switch uv := v.(type) {
+	case reflect.Value:
+		if uv.IsValid() {
+			return uv.Interface()
+		}
+	case Nullable:
+		if uv.Null {
+			return nil
+		}
+		return uv.Value
+	}
+	return v
+}
@@ -0,0 +1,225 @@
+package runner
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	"github.com/pkg/errors"
+)
+
+// jsonObject is a JSON object whose keys are written in the order they
+// were given.
+type jsonObject []jsonField
+
+type jsonField struct {
+	key   string
+	value interface{}
+}
+
+// jsonArray is a JSON array.
+type jsonArray []interface{}
+
+// jsonOptional is a value that's left out of the object or array that
+// it's in some of the time.
+type jsonOptional struct {
+	value interface{}
+	omit  bool
+}
+
+// MarshalJSON writes the object's fields in order, leaving out any
+// omitted optional values.
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.WriteByte('{')
+
+	var written int
+	for _, f := range o {
+		v, ok := jsonValue(f.value)
+		if !ok {
+			continue
+		}
+
+		if written > 0 {
+			buf.WriteByte(',')
+		}
+		written++
+
+		if err := writeJSON(&buf, f.key); err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		if err := writeJSON(&buf, v); err != nil {
+			return nil, errors.Wrapf(err, "writing %q", f.key)
+		}
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// MarshalJSON writes the array's values, leaving out any omitted
+// optional values.
+func (a jsonArray) MarshalJSON() ([]byte, error) {
+	values := []interface{}{}
+	for _, v := range a {
+		if v, ok := jsonValue(v); ok {
+			values = append(values, v)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	if err := writeJSON(&buf, values); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// String returns the object as JSON, so that it's written as JSON when
+// it isn't passed to json, such as by record.
+func (o jsonObject) String() string {
+	return jsonText(o)
+}
+
+// Value returns the object as JSON, so that records containing it can be
+// copied into a database.
+func (o jsonObject) Value() (driver.Value, error) {
+	return encodeJSON(o)
+}
+
+// String returns the array as JSON.
+func (a jsonArray) String() string {
+	return jsonText(a)
+}
+
+// Value returns the array as JSON.
+func (a jsonArray) Value() (driver.Value, error) {
+	return encodeJSON(a)
+}
+
+func jsonText(v interface{}) string {
+	doc, err := encodeJSON(v)
+	if err != nil {
+		return fmt.Sprintf("%%!(json error: %v)", err)
+	}
+	return doc
+}
+
+func encodeJSON(v interface{}) (string, error) {
+	buf := bytes.Buffer{}
+	if err := writeJSON(&buf, v); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// jsonValue returns the value to write for a value given to a template
+// function, and false if it should be left out.
+func jsonValue(v interface{}) (interface{}, bool) {
+	if o, ok := v.(jsonOptional); ok {
+		if o.omit {
+			return nil, false
+		}
+		v = o.value
+	}
+
+	v = unwrap(v)
+
+	// Text scanned out of databases is held as bytes, which would
+	// otherwise be written as base64.
+	if b, ok := v.([]byte); ok {
+		return string(b), true
+	}
+	return v, true
+}
+
+func writeJSON(buf *bytes.Buffer, v interface{}) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+
+	// Encode terminates each value with a newline.
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// jsonObj returns a JSON object from pairs of keys and values.
+func jsonObj(pairs ...interface{}) (jsonObject, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("expected pairs of keys and values, got %d arguments", len(pairs))
+	}
+
+	o := make(jsonObject, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := unwrap(pairs[i]).(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string key, got %v (%T)", pairs[i], pairs[i])
+		}
+		o = append(o, jsonField{key: key, value: pairs[i+1]})
+	}
+
+	return o, nil
+}
+
+// jsonArr returns a JSON array of values.
+func jsonArr(values ...interface{}) jsonArray {
+	return jsonArray(values)
+}
+
+// jsonOpt returns a value that's left out of its object or array for the
+// given percentage of calls.
+func jsonOpt(percent float64, v interface{}) (jsonOptional, error) {
+	if percent < 0 || percent > 100 {
+		return jsonOptional{}, fmt.Errorf("percentage must be between 0 and 100, got %v", percent)
+	}
+
+	return jsonOptional{value: v, omit: rand.Float64()*100 < percent}, nil
+}
+
+// jsonArrN returns a JSON array of between min and max values, each
+// generated by calling the named template function.
+func (r *Runner) jsonArrN(min, max int, name string, args ...interface{}) (jsonArray, error) {
+	if min < 0 || max < min {
+		return nil, fmt.Errorf("invalid array length range %d to %d", min, max)
+	}
+
+	fn, ok := r.funcs[name]
+	if !ok {
+		return nil, fmt.Errorf("function %q not found", name)
+	}
+
+	a := make(jsonArray, min+rand.Intn(max-min+1))
+	for i := range a {
+		v, err := callFunc(fn, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "calling %q", name)
+		}
+		a[i] = v
+	}
+
+	return a, nil
+}
+
+// jsonSQL serialises a value as JSON, written as a quoted SQL string
+// literal.  Omitted optional values and NULL Nullables are written as
+// NULL, rather than as JSON.
+func (r *Runner) jsonSQL(v interface{}) (string, error) {
+	if n, ok := v.(Nullable); ok && n.Null {
+		return "NULL", nil
+	}
+
+	v, ok := jsonValue(v)
+	if !ok {
+		return "NULL", nil
+	}
+
+	doc, err := encodeJSON(v)
+	if err != nil {
+		return "", errors.Wrap(err, "writing json")
+	}
+	return r.sqlString(doc), nil
+}
@@ -0,0 +1,236 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestJSON(t *testing.T) {
+	cases := []struct {
+		name     string
+		opts     []Option
+		body     string
+		exp      string
+		expError bool
+	}{
+		{name: "object", body: `{{json (jobj "name" "Alice" "age" 42 "admin" true)}}`, exp: `'{"name":"Alice","age":42,"admin":true}'`},
+		{name: "nested", body: `{{json (jobj "tags" (jarr "a" "b") "address" (jobj "city" "Berlin"))}}`, exp: `'{"tags":["a","b"],"address":{"city":"Berlin"}}'`},
+		{name: "escaping", body: `{{json (jobj "quote" "O'Brien \"Bob\"" "html" "<b>&</b>" "lines" "a\nb")}}`, exp: `'{"quote":"O''Brien \"Bob\"","html":"<b>&</b>","lines":"a\nb"}'`},
+		{name: "optional keys", body: `{{json (jobj "always" (jopt 0 1) "never" (jopt 100 2))}}`, exp: `'{"always":1}'`},
+		{name: "optional items", body: `{{json (jarr (jopt 100 1) 2 (jopt 0 3))}}`, exp: `'[2,3]'`},
+		{name: "nullable", body: `{{json (jobj "a" (nullable 100 1) "b" (nullable 0 "x"))}}`, exp: `'{"a":null,"b":"x"}'`},
+		{name: "null document", body: `{{json (nullable 100 (jobj "a" 1))}}`, exp: `NULL`},
+		{name: "variable length array", body: `{{json (jarrn 3 3 "set" "x")}}`, exp: `'["x","x","x"]'`},
+		{name: "empty object", body: `{{json (jobj)}}`, exp: `'{}'`},
+		{name: "mysql escaping", opts: []Option{WithDriver("mysql")}, body: `{{json (jobj "quote" "O'Brien \"Bob\"" "lines" "a\nb")}}`, exp: `'{"quote":"O''Brien \\"Bob\\"","lines":"a\\nb"}'`},
+		{name: "object without json", body: `{{jobj "a" (jarr 1 "x") "b" (jopt 100 2)}}`, exp: `{"a":[1,"x"]}`},
+		{name: "array without json", body: `{{jarr (jobj "a" 1) 2}}`, exp: `[{"a":1},2]`},
+		{name: "odd arguments", body: `{{json (jobj "a")}}`, expError: true},
+		{name: "non-string key", body: `{{json (jobj 1 2)}}`, expError: true},
+		{name: "invalid optional percentage", body: `{{json (jopt 101 1)}}`, expError: true},
+		{name: "invalid array length", body: `{{json (jarrn 3 2 "set" "x")}}`, expError: true},
+		{name: "unknown function", body: `{{json (jarrn 1 2 "missing")}}`, expError: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			r := mustNew(t, sink.NewWriter(buf), c.opts...)
+
+			err := r.Run(parse.Block{Repeat: 1, Name: "a", Body: c.body})
+			test.ErrorExists(t, c.expError, err)
+			if err != nil {
+				return
+			}
+
+			test.ErrorExists(t, false, r.Close())
+			test.Equals(t, c.exp+";\n", buf.String())
+		})
+	}
+}
+
+func TestJSONArrN(t *testing.T) {
+	r := mustNew(t, nil)
+
+	lengths := map[int]bool{}
+	for i := 0; i < 1000; i++ {
+		a, err := r.jsonArrN(1, 3, "int", 1, 10)
+		test.ErrorExists(t, false, err)
+		lengths[len(a)] = true
+
+		for _, v := range a {
+			test.Assert(t, v.(int64) >= 1 && v.(int64) < 10)
+		}
+	}
+
+	test.Equals(t, map[int]bool{1: true, 2: true, 3: true}, lengths)
+}
+
+func TestJSONFromSchema(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"required": ["id", "email", "status", "score", "tags", "sku", "meta"],
+		"properties": {
+			"id": {"type": "string", "format": "uuid"},
+			"email": {"type": "string", "format": "email"},
+			"status": {"enum": ["active", "inactive"]},
+			"score": {"type": "integer", "minimum": 1, "maximum": 5},
+			"tags": {"type": "array", "items": {"type": "string", "minLength": 3, "maxLength": 3}, "minItems": 1, "maxItems": 3},
+			"sku": {"type": "string", "pattern": "^[A-Z]{3}-[0-9]{4}$"},
+			"meta": {"type": "object", "properties": {"version": {"const": 2}}, "required": ["version"]},
+			"note": {"type": ["string", "null"]}
+		}
+	}`
+
+	r := mustNew(t, nil)
+	for i := 0; i < 100; i++ {
+		v, err := r.jsonFromSchema(schema)
+		test.ErrorExists(t, false, err)
+
+		b, err := json.Marshal(v)
+		test.ErrorExists(t, false, err)
+
+		// Properties are written in the schema's order.
+		test.Assert(t, regexp.MustCompile(`^\{"id":.*,"email":.*,"status":.*,"score":.*,"tags":.*,"sku":.*,"meta":\{"version":2\}(,"note":.*)?\}$`).MatchString(string(b)))
+
+		var doc struct {
+			ID     string   `json:"id"`
+			Email  string   `json:"email"`
+			Status string   `json:"status"`
+			Score  int      `json:"score"`
+			Tags   []string `json:"tags"`
+			SKU    string   `json:"sku"`
+		}
+		test.ErrorExists(t, false, json.Unmarshal(b, &doc))
+
+		test.Assert(t, regexp.MustCompile(`^[0-9a-f-]{36}$`).MatchString(doc.ID))
+		test.Assert(t, strings.Contains(doc.Email, "@"))
+		test.Assert(t, doc.Status == "active" || doc.Status == "inactive")
+		test.Assert(t, doc.Score >= 1 && doc.Score <= 5)
+		test.Assert(t, len(doc.Tags) >= 1 && len(doc.Tags) <= 3)
+		for _, tag := range doc.Tags {
+			test.Equals(t, 3, len(tag))
+		}
+		test.Assert(t, regexp.MustCompile(`^[A-Z]{3}-[0-9]{4}$`).MatchString(doc.SKU))
+	}
+}
+
+func TestJSONFromSchemaBounds(t *testing.T) {
+	cases := []struct {
+		name   string
+		schema string
+		check  func(v interface{}) bool
+	}{
+		{
+			name:   "max length below default minimum",
+			schema: `{"type": "string", "maxLength": 3}`,
+			check:  func(v interface{}) bool { return len(v.(string)) <= 3 },
+		},
+		{
+			name:   "max items only",
+			schema: `{"type": "array", "items": {"type": "null"}, "maxItems": 2}`,
+			check:  func(v interface{}) bool { return len(v.(jsonArray)) <= 2 },
+		},
+		{
+			name:   "largest integers",
+			schema: `{"type": "integer", "minimum": 9223372036854775806, "maximum": 9223372036854775807}`,
+			check:  func(v interface{}) bool { return v.(int64) >= math.MaxInt64-1 },
+		},
+		{
+			name:   "smallest integers",
+			schema: `{"type": "integer", "maximum": -9223372036854775808}`,
+			check:  func(v interface{}) bool { return v.(int64) == math.MinInt64 },
+		},
+		{
+			name:   "whole integer range",
+			schema: `{"type": "integer", "minimum": -9223372036854775808, "maximum": 9223372036854775807}`,
+			check:  func(v interface{}) bool { return true },
+		},
+		{
+			name:   "integer bounds beyond int64",
+			schema: `{"type": "integer", "minimum": 1e30}`,
+			check:  func(v interface{}) bool { return v.(int64) == math.MaxInt64 },
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := mustNew(t, nil)
+			for i := 0; i < 100; i++ {
+				v, err := r.jsonFromSchema(c.schema)
+				test.ErrorExists(t, false, err)
+				test.Assert(t, c.check(v))
+			}
+		})
+	}
+}
+
+func TestJSONFromSchemaConst(t *testing.T) {
+	cases := []struct {
+		name   string
+		schema string
+		exp    interface{}
+	}{
+		{name: "string", schema: `{"const": "active"}`, exp: "active"},
+		{name: "number", schema: `{"const": 2}`, exp: float64(2)},
+		{name: "null", schema: `{"const": null}`, exp: nil},
+		{name: "array", schema: `{"const": ["a", 1]}`, exp: []interface{}{"a", float64(1)}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := mustNew(t, nil)
+			v, err := r.jsonFromSchema(c.schema)
+			test.ErrorExists(t, false, err)
+			test.Equals(t, c.exp, v)
+		})
+	}
+}
+
+func TestJSONFromSchemaFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.json")
+	test.ErrorExists(t, false, os.WriteFile(path, []byte(`{"type": "boolean"}`), 0644))
+
+	r := mustNew(t, nil)
+	v, err := r.jsonFromSchema(path)
+	test.ErrorExists(t, false, err)
+
+	_, ok := v.(bool)
+	test.Assert(t, ok)
+}
+
+func TestJSONFromSchemaErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		schema string
+	}{
+		{name: "invalid json", schema: `{"type": `},
+		{name: "missing file", schema: "missing.json"},
+		{name: "no type", schema: `{}`},
+		{name: "unsupported type", schema: `{"type": "date"}`},
+		{name: "array without items", schema: `{"type": "array"}`},
+		{name: "invalid bounds", schema: `{"type": "integer", "minimum": 5, "maximum": 1}`},
+		{name: "no integers in bounds", schema: `{"type": "integer", "minimum": 1.2, "maximum": 1.8}`},
+		{name: "invalid string length", schema: `{"type": "string", "minLength": 5, "maxLength": 1}`},
+		{name: "invalid pattern", schema: `{"type": "string", "pattern": "[a-"}`},
+		{name: "invalid property", schema: `{"type": "object", "required": ["a"], "properties": {"a": {"type": "date"}}}`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := mustNew(t, nil)
+			_, err := r.jsonFromSchema(c.schema)
+			test.ErrorExists(t, true, err)
+		})
+	}
+}
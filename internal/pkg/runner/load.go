@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"context"
+	"math/rand"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/pkg/errors"
+)
+
+// load runs a block's query against the database, keeping the rows
+// returned under the block's load name without inserting anything.
+// When the sink can't read from a database, rows are simulated from the
+// block's RETURNS comment instead.
+func (r *Runner) load(ctx context.Context, b parse.Block, stmt string) error {
+	reader, ok := r.sink.(sink.Reader)
+	if !ok {
+		if b.Returns == nil {
+			return errors.New("loading rows requires a database or a RETURNS comment")
+		}
+
+		count := b.Load.Limit
+		if count == 0 {
+			count = 1
+		}
+		return r.simulateRows(b.Load.Name, b.Returns, insert{}, count)
+	}
+
+	rows, err := reader.Read(ctx, stmt)
+	if err != nil {
+		r.mustDumpQuery([]byte(stmt))
+		return errors.Wrap(err, "executing query")
+	}
+	defer rows.Close()
+
+	limit := b.Load.Limit
+	sample := []map[string]interface{}{}
+
+	for seen := 0; rows.Next(); seen++ {
+		if limit > 0 && len(sample) == limit && !b.Load.Random {
+			break
+		}
+
+		curr, err := r.scanRow(rows)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case limit == 0 || len(sample) < limit:
+			sample = append(sample, curr)
+		default:
+			// Reservoir sampling keeps a uniformly random subset of the
+			// rows without knowing how many there are up-front.
+			if i := rand.Intn(seen + 1); i < limit {
+				sample[i] = curr
+			}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return errors.Wrap(err, "reading rows")
+	}
+
+	for _, row := range sample {
+		if err = r.store.set(b.Load.Name, row); err != nil {
+			return errors.Wrap(err, "storing row")
+		}
+	}
+	return nil
+}
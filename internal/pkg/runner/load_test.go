@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"bytes"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestRunLoad(t *testing.T) {
+	sqlite, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "sandbox.db"))
+	if err != nil {
+		t.Fatalf("error opening sqlite: %v", err)
+	}
+	defer sqlite.Close()
+
+	_, err = sqlite.Exec(`
+		create table "customer" ("id" integer primary key);
+		with recursive n(i) as (select 1 union all select i + 1 from n where i < 50) insert into "customer" ("id") select i from n;
+		create table "order" ("id" integer primary key autoincrement, "cid" integer not null references "customer" ("id"));`)
+	if err != nil {
+		t.Fatalf("error creating tables: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		load   parse.Load
+		expLen int
+		expIDs []int64
+	}{
+		{
+			name:   "all rows",
+			load:   parse.Load{Name: "customer"},
+			expLen: 50,
+		},
+		{
+			name:   "first rows",
+			load:   parse.Load{Name: "customer", Limit: 3},
+			expLen: 3,
+			expIDs: []int64{1, 2, 3},
+		},
+		{
+			name:   "random rows",
+			load:   parse.Load{Name: "customer", Limit: 10, Random: true},
+			expLen: 10,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Wrapping the sink shouldn't stop it being read from.
+			r := mustNew(t, struct{ *sink.Database }{sink.NewDatabase(sqlite)})
+
+			load := c.load
+			test.ErrorExists(t, false, r.Run(parse.Block{Load: &load, Body: `select "id" from "customer" order by "id"`}))
+			test.ErrorExists(t, false, r.Run(parse.Block{Body: `insert into "order" ("cid") values ({{ref "customer" "id"}})`}))
+
+			results, err := r.Results()
+			test.ErrorExists(t, false, err)
+			test.Equals(t, c.expLen, len(results["customer"]))
+			for i, id := range c.expIDs {
+				test.Equals(t, id, results["customer"][i]["id"])
+			}
+
+			var customers int
+			test.ErrorExists(t, false, sqlite.QueryRow(`select count(*) from "customer"`).Scan(&customers))
+			test.Equals(t, 50, customers)
+		})
+	}
+}
+
+func TestRunLoadWithoutDatabase(t *testing.T) {
+	r := mustNew(t, sink.NewWriter(&bytes.Buffer{}))
+
+	b := parse.Block{Load: &parse.Load{Name: "customer", Limit: 5}, Body: `select "id" from "customer"`}
+	test.ErrorExists(t, true, r.Run(b))
+
+	b.Returns = []parse.Column{{Name: "id", Type: "int"}}
+	test.ErrorExists(t, false, r.Run(b))
+
+	results, err := r.Results()
+	test.ErrorExists(t, false, err)
+	test.Equals(t, 5, len(results["customer"]))
+}
@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/Pallinder/go-randomdata"
+	"github.com/codingconcepts/datagen/internal/pkg/locale"
+)
+
+// localised returns a template function that generates personal data for
+// the locale passed to it, or the default locale otherwise.  Without
+// either, the fallback function is used.
+func (r *Runner) localised(fallback func() string, fn func(*locale.Locale) string) func(code ...string) (string, error) {
+	return func(code ...string) (string, error) {
+		l, err := r.localeFor(code)
+		if err != nil {
+			return "", err
+		}
+		if l == nil {
+			return fallback(), nil
+		}
+		return fn(l), nil
+	}
+}
+
+// localeFor returns the locale passed to a template function, or the
+// default locale otherwise, which is nil if it hasn't been set.
+func (r *Runner) localeFor(code []string) (*locale.Locale, error) {
+	switch len(code) {
+	case 0:
+		return r.locale, nil
+	case 1:
+		return locale.Get(code[0])
+	default:
+		return nil, fmt.Errorf("expected at most one locale, got %d", len(code))
+	}
+}
+
+// street returns a random street name for a locale.  Country codes
+// without an embedded locale use go-randomdata instead.
+func (r *Runner) street(code ...string) (string, error) {
+	if country, ok := randomdataCountry(code); ok {
+		return randomdata.StreetForCountry(country), nil
+	}
+
+	return r.localised(randomdata.Street, (*locale.Locale).Street)(code...)
+}
+
+// postcode returns a random postcode for a locale.  Country codes
+// without an embedded locale use go-randomdata instead.
+func (r *Runner) postcode(code ...string) (string, error) {
+	if country, ok := randomdataCountry(code); ok {
+		return randomdata.PostalCode(country), nil
+	}
+
+	return r.localised(func() string { return randomdata.PostalCode("US") }, (*locale.Locale).Postcode)(code...)
+}
+
+// randomdataCountry returns the country code passed to a template
+// function if there's no embedded locale for it, so go-randomdata's
+// data for the country can be used instead.
+func randomdataCountry(code []string) (string, bool) {
+	if len(code) != 1 || len(code[0]) != 2 {
+		return "", false
+	}
+
+	_, err := locale.Get(code[0])
+	return code[0], err != nil
+}
+
+// address returns a random address for a locale, whose fields are
+// consistent with each other.  Without a locale, an American address is
+// returned.
+func (r *Runner) address(code ...string) (locale.Address, error) {
+	l, err := r.localeOrUS(code)
+	if err != nil {
+		return locale.Address{}, err
+	}
+	return l.Address(), nil
+}
+
+// person returns a random person for a locale, whose email address is
+// derived from their name.  Without a locale, an American person is
+// returned.
+func (r *Runner) person(code ...string) (locale.Person, error) {
+	l, err := r.localeOrUS(code)
+	if err != nil {
+		return locale.Person{}, err
+	}
+	return l.Person(), nil
+}
+
+func (r *Runner) localeOrUS(code []string) (*locale.Locale, error) {
+	l, err := r.localeFor(code)
+	if err != nil || l != nil {
+		return l, err
+	}
+	return locale.Get("en_US")
+}
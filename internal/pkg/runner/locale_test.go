@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestLocalised(t *testing.T) {
+	cases := []struct {
+		name     string
+		opts     []Option
+		body     string
+		exp      string
+		expError bool
+	}{
+		{name: "given locale", body: `{{postcode "de_DE"}}`, exp: `^\d{5};\n$`},
+		{name: "given non-latin locale", body: `{{name "ja_JP"}}`, exp: `^\p{Han}+ \p{Han}*\p{Hiragana}*;\n$`},
+		{name: "default locale", opts: []Option{WithLocale("pt-BR")}, body: `{{phone}}`, exp: `^\(\d{2}\) \d{4,5}-\d{4};\n$`},
+		{name: "given locale overrides default", opts: []Option{WithLocale("pt_BR")}, body: `{{postcode "ja_JP"}}`, exp: `^\d{3}-\d{4};\n$`},
+		{name: "country code", opts: []Option{WithLocale("ja_JP")}, body: `{{postcode "GB"}}`, exp: `^[A-Z]{1,2}\d`},
+		{name: "country code with locale", body: `{{street "DE"}}|{{name "DE"}}`, exp: `^\pL.+\|\pL+ \pL+;\n$`},
+		{name: "country code without locale", body: `{{postcode "FR"}}`, exp: `^\d{5};\n$`},
+		{name: "country code without locale unsupported", body: `{{name "FR"}}`, expError: true},
+		{name: "no locale", body: `{{postcode}}`, exp: `^\d{5};\n$`},
+		{name: "unsupported locale", body: `{{city "xx_XX"}}`, expError: true},
+		{name: "too many locales", body: `{{street "de_DE" "ja_JP"}}`, expError: true},
+		{name: "address", body: `{{address "US"}}`, exp: `^\d+ .+, .+, [A-Z]{2} \d{5};\n$`},
+		{name: "address fields", body: `{{$a := address "de_DE"}}{{$a.City}}|{{$a.Postcode}}|{{$a.CountryCode}}`, exp: `^\pL.*\|\d{5}\|DE;\n$`},
+		{name: "person", opts: []Option{WithLocale("pt_BR")}, body: `{{$p := person}}{{$p.Name}}|{{$p.Email}}|{{$p.Address.StateCode}}`, exp: `^\pL+ \pL+\|[a-z]+[._]?[a-z]+\d*@[a-z.]+\|[A-Z]{2};\n$`},
+		{name: "person without locale", body: `{{$p := person}}{{$p}}|{{$p.Address.CountryCode}}`, exp: `^[A-Za-z]+ [A-Za-z]+\|US;\n$`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			r := mustNew(t, sink.NewWriter(buf), c.opts...)
+
+			err := r.Run(parse.Block{Repeat: 1, Name: "a", Body: c.body})
+			test.ErrorExists(t, c.expError, err)
+			if err != nil {
+				return
+			}
+
+			test.ErrorExists(t, false, r.Close())
+			if !regexp.MustCompile(c.exp).MatchString(buf.String()) {
+				t.Fatalf("%q doesn't match %q", buf.String(), c.exp)
+			}
+		})
+	}
+}
+
+func TestWithLocaleUnsupported(t *testing.T) {
+	_, err := New(nil, WithLocale("xx_XX"))
+	test.ErrorExists(t, true, err)
+}
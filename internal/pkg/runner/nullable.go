@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Nullable is a value that's NULL some of the time.  Templates write it
+// as NULL or as a SQL literal, so it mustn't be wrapped in quotes, and
+// record passes it on as nil or its value.
+type Nullable struct {
+	// Value is the value when it isn't NULL.
+	Value interface{}
+
+	// Null is true when the value is NULL.
+	Null bool
+
+	literal string
+}
+
+// String returns NULL or the value as a SQL literal.
+func (n Nullable) String() string {
+	if n.Null {
+		return "NULL"
+	}
+	return n.literal
+}
+
+// nullable returns a Nullable that's NULL for the given percentage of
+// calls and holds a value otherwise.
+func (r *Runner) nullable(percent float64, v interface{}) (Nullable, error) {
+	if percent < 0 || percent > 100 {
+		return Nullable{}, fmt.Errorf("percentage must be between 0 and 100, got %v", percent)
+	}
+
+	v = unwrap(v)
+	if v == nil || rand.Float64()*100 < percent {
+		return Nullable{Null: true}, nil
+	}
+
+	return Nullable{Value: v, literal: r.sqlLiteral(v)}, nil
+}
+
+// sqlLiteral formats a value as a SQL literal, quoting anything that
+// isn't a number or a boolean.
+func (r *Runner) sqlLiteral(v interface{}) string {
+	switch v := v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
+		return fmt.Sprint(v)
+	case time.Time:
+		return r.sqlString(v.Format(r.dateFormat))
+	case []byte:
+		return r.sqlString(string(v))
+	default:
+		return r.sqlString(fmt.Sprint(v))
+	}
+}
+
+// sqlString writes a string as a SQL string literal for the Runner's
+// driver.  MySQL treats backslashes as escape characters, so they're
+// escaped too.
+func (r *Runner) sqlString(s string) string {
+	if r.driver == "mysql" {
+		s = strings.ReplaceAll(s, `\`, `\\`)
+	}
+	return quote(s)
+}
+
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,132 @@
+package runner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestNullable(t *testing.T) {
+	r := mustNew(t, nil, WithDateFormat("20060102"))
+
+	cases := []struct {
+		name     string
+		percent  float64
+		value    interface{}
+		exp      string
+		expValue interface{}
+		expError bool
+	}{
+		{name: "string", percent: 0, value: "Alice", exp: "'Alice'", expValue: "Alice"},
+		{name: "string with quotes", percent: 0, value: "O'Brien", exp: "'O''Brien'", expValue: "O'Brien"},
+		{name: "int", percent: 0, value: int64(42), exp: "42", expValue: int64(42)},
+		{name: "float", percent: 0, value: 1.5, exp: "1.5", expValue: 1.5},
+		{name: "bool", percent: 0, value: true, exp: "true", expValue: true},
+		{name: "time", percent: 0, value: time.Date(2019, time.July, 8, 9, 0, 1, 0, time.UTC), exp: "'20190708'", expValue: time.Date(2019, time.July, 8, 9, 0, 1, 0, time.UTC)},
+		{name: "nil", percent: 0, value: nil, exp: "NULL"},
+		{name: "always null", percent: 100, value: "Alice", exp: "NULL"},
+		{name: "percentage too small", percent: -1, value: "Alice", expError: true},
+		{name: "percentage too large", percent: 101, value: "Alice", expError: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			n, err := r.nullable(c.percent, c.value)
+			test.ErrorExists(t, c.expError, err)
+			if err != nil {
+				return
+			}
+
+			test.Equals(t, c.exp, n.String())
+			test.Equals(t, c.expValue, unwrap(n))
+		})
+	}
+}
+
+func TestSQLString(t *testing.T) {
+	cases := []struct {
+		name   string
+		driver string
+		exp    string
+	}{
+		{name: "default", exp: `'O''Brien \n'`},
+		{name: "postgres", driver: "postgres", exp: `'O''Brien \n'`},
+		{name: "mysql", driver: "mysql", exp: `'O''Brien \\n'`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := mustNew(t, nil, WithDriver(c.driver))
+			test.Equals(t, c.exp, r.sqlString(`O'Brien \n`))
+		})
+	}
+}
+
+func TestNullablePercentage(t *testing.T) {
+	r := mustNew(t, nil)
+
+	var nulls int
+	for i := 0; i < 100000; i++ {
+		n, err := r.nullable(15, "a")
+		test.ErrorExists(t, false, err)
+		if n.Null {
+			nulls++
+		}
+	}
+
+	test.Assert(t, nulls > 14000 && nulls < 16000)
+}
+
+func TestRunNullable(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := mustNew(t, sink.NewWriter(buf))
+
+	b := parse.Block{
+		Repeat: 1,
+		Name:   "owner",
+		Body:   `insert into "owner" ("name", "age") values ({{nullable 0 (set "O'Brien")}}, {{nullable 100 (int 1 10)}})`,
+	}
+
+	test.ErrorExists(t, false, r.Run(b))
+	test.ErrorExists(t, false, r.Close())
+	test.Equals(t, "insert into \"owner\" (\"name\", \"age\") values ('O''Brien', NULL);\n", buf.String())
+}
+
+func TestRunNullableRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "owners.csv")
+	r := mustNew(t, sink.NewWriter(&bytes.Buffer{}))
+
+	b := parse.Block{
+		Repeat:  1,
+		Name:    "owner",
+		Body:    `{{record (nullable 0 "a") (nullable 100 "b")}}`,
+		Columns: []string{"present", "absent"},
+		Output: &parse.Output{
+			Format:  "csv",
+			Path:    path,
+			Options: map[string]string{"null": `\N`},
+		},
+	}
+
+	test.ErrorExists(t, false, r.Run(b))
+	test.ErrorExists(t, false, r.Close())
+
+	act, err := ioutil.ReadFile(path)
+	test.ErrorExists(t, false, err)
+	test.Equals(t, "present,absent\na,\\N\n", string(act))
+
+	// Records are kept with their values, rather than as Nullables.
+	present, err := r.store.reference("owner", "present")
+	test.ErrorExists(t, false, err)
+	test.Equals(t, "a", present)
+
+	absent, err := r.store.reference("owner", "absent")
+	test.ErrorExists(t, false, err)
+	test.Equals(t, nil, absent)
+}
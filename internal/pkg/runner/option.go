@@ -1,6 +1,11 @@
 package runner
 
-import "github.com/codingconcepts/datagen/internal/pkg/random"
+import (
+	"text/template"
+	"time"
+
+	"github.com/codingconcepts/datagen/internal/pkg/random"
+)
 
 // Option allows the Runner to be configured by the user.
 type Option func(*Runner)
@@ -20,10 +25,113 @@ func WithStringFDefaults(d random.StringFDefaults) Option {
 	}
 }
 
-// WithDebug puts the Runner in debug mode, meaning nothing will be
-// written to a database.
-func WithDebug(d bool) Option {
+// WithBatchSize sets the maximum number of records written by each
+// COPY statement.
+func WithBatchSize(n int) Option {
+	return func(r *Runner) {
+		r.batchSize = n
+	}
+}
+
+// WithProgress registers a function that is called with the number of
+// records written each time a batch of records is flushed.
+func WithProgress(f func(rows int)) Option {
+	return func(r *Runner) {
+		r.progress = f
+	}
+}
+
+// WithQueryErrFile sets the path of the file that failed queries are
+// written to.  An empty path disables writing failed queries.
+func WithQueryErrFile(path string) Option {
+	return func(r *Runner) {
+		r.queryErrFile = path
+	}
+}
+
+// WithFuncs adds custom functions to those available to templates.  An
+// error will be returned by New if any of the functions share their name
+// with a built-in function.
+func WithFuncs(funcs template.FuncMap) Option {
+	return func(r *Runner) {
+		for name, fn := range funcs {
+			r.customFuncs[name] = customFunc{fn: fn}
+		}
+	}
+}
+
+// WithFuncOverrides adds custom functions to those available to
+// templates, replacing any built-in functions that share their names.
+func WithFuncOverrides(funcs template.FuncMap) Option {
+	return func(r *Runner) {
+		for name, fn := range funcs {
+			r.customFuncs[name] = customFunc{fn: fn, override: true}
+		}
+	}
+}
+
+// WithPluginTimeout sets how long to wait for a plugin to respond to a
+// call, or to exit once it's closed.
+func WithPluginTimeout(d time.Duration) Option {
+	return func(r *Runner) {
+		r.pluginTimeout = d
+	}
+}
+
+// WithDiskStore keeps the rows returned by each block in a temporary file
+// in dir, rather than in memory, allowing far more rows to be referenced.
+// The default directory for temporary files is used if dir is empty.
+func WithDiskStore(dir string) Option {
+	return func(r *Runner) {
+		r.diskStore = true
+		r.diskStoreDir = dir
+	}
+}
+
+// WithUniqueAttempts sets the number of times unique calls a generator
+// before giving up on finding a value that hasn't been seen.  Defaults
+// to 100.
+func WithUniqueAttempts(n int) Option {
+	return func(r *Runner) {
+		r.uniqueAttempts = n
+	}
+}
+
+// WithUniqueBloomFilter tracks the values seen by unique with bloom
+// filters sized to hold n values per key with the given rate of false
+// positives, which must be between 0 and 1, rather than holding every
+// value in memory.  False positives cause unique values to be rejected,
+// never duplicates to be accepted.
+func WithUniqueBloomFilter(n int, falsePositiveRate float64) Option {
+	return func(r *Runner) {
+		r.uniqueFilterSize = n
+		r.uniqueFilterRate = falsePositiveRate
+	}
+}
+
+// WithSequenceFile sets the path of a file that the last value of each
+// sequence is loaded from and saved to when the Runner is closed, so
+// sequences continue from where the previous run left off.
+func WithSequenceFile(path string) Option {
+	return func(r *Runner) {
+		r.sequenceFile = path
+	}
+}
+
+// WithDriver sets the name of the database driver that statements are
+// written for, such as "mysql", which affects how string literals are
+// escaped.
+func WithDriver(driver string) Option {
+	return func(r *Runner) {
+		r.driver = driver
+	}
+}
+
+// WithLocale sets the default locale of personal data, such as names and
+// addresses, for template functions that aren't given one.  An error will
+// be returned by New if the locale isn't supported.
+func WithLocale(code string) Option {
 	return func(r *Runner) {
-		r.debug = d
+		r.localeCode = code
 	}
 }
@@ -1,22 +1,26 @@
 package runner
 
 import (
+	"bytes"
 	"testing"
+	"text/template"
 	"time"
 
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
 	"github.com/codingconcepts/datagen/internal/pkg/random"
 
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
 	"github.com/codingconcepts/datagen/internal/pkg/test"
 )
 
 func TestWithDateFormat(t *testing.T) {
-	r := New(db, WithDateFormat(time.RFC3339))
+	r := mustNew(t, sink.NewDatabase(db), WithDateFormat(time.RFC3339))
 
 	test.Equals(t, time.RFC3339, r.dateFormat)
 }
 
 func TestWithStringFDefaults(t *testing.T) {
-	r := New(db, WithStringFDefaults(random.StringFDefaults{
+	r := mustNew(t, sink.NewDatabase(db), WithStringFDefaults(random.StringFDefaults{
 		IntMinDefault:    1,
 		IntMaxDefault:    2,
 		StringMinDefault: 3,
@@ -28,3 +32,55 @@ func TestWithStringFDefaults(t *testing.T) {
 	test.Equals(t, int64(3), r.stringFdefaults.StringMinDefault)
 	test.Equals(t, int64(4), r.stringFdefaults.StringMaxDefault)
 }
+
+func TestWithFuncs(t *testing.T) {
+	sku := func() string { return "SKU-1" }
+
+	cases := []struct {
+		name     string
+		opts     []Option
+		exp      string
+		expError bool
+	}{
+		{
+			name: "new function",
+			opts: []Option{WithFuncs(template.FuncMap{"sku": sku})},
+			exp:  "SKU-1",
+		},
+		{
+			name:     "collision",
+			opts:     []Option{WithFuncs(template.FuncMap{"uuid": sku})},
+			expError: true,
+		},
+		{
+			name: "override",
+			opts: []Option{WithFuncOverrides(template.FuncMap{"sku": sku, "uuid": sku})},
+			exp:  "SKU-1",
+		},
+		{
+			name:     "not a function",
+			opts:     []Option{WithFuncs(template.FuncMap{"sku": "SKU-1"})},
+			expError: true,
+		},
+		{
+			name:     "too many return values",
+			opts:     []Option{WithFuncs(template.FuncMap{"sku": func() (string, string) { return "", "" }})},
+			expError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			r, err := New(sink.NewWriter(buf), c.opts...)
+			test.ErrorExists(t, c.expError, err)
+			if err != nil {
+				return
+			}
+
+			test.ErrorExists(t, false, r.Run(parse.Block{Body: `{{sku}}`}))
+			test.ErrorExists(t, false, r.Close())
+			test.Equals(t, c.exp+";\n", buf.String())
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/pkg/errors"
+)
+
+// record collects a row of values to be written by blocks that declare
+// an OUTPUT or COPY, returning an empty string so it can be called
+// inline.
+func (r *Runner) record(values ...interface{}) string {
+	for i, v := range values {
+		values[i] = unwrap(v)
+	}
+
+	r.records = append(r.records, values)
+	return ""
+}
+
+// keepRecords makes the records collected during the execution of a
+// block available to later blocks, keyed by the block's columns.
+func (r *Runner) keepRecords(b parse.Block) error {
+	if len(b.Columns) == 0 {
+		return nil
+	}
+
+	for _, rec := range r.records {
+		if len(rec) != len(b.Columns) {
+			return fmt.Errorf("record has %d values but block declares %d columns", len(rec), len(b.Columns))
+		}
+
+		row := map[string]interface{}{}
+		for i, c := range b.Columns {
+			row[c] = rec[i]
+		}
+		if err := r.store.set(b.Name, row); err != nil {
+			return errors.Wrap(err, "storing record")
+		}
+	}
+
+	return nil
+}
+
+// output writes the result of executing a block to the block's OUTPUT
+// and makes it available to later blocks.
+func (r *Runner) output(b parse.Block, body string) error {
+	switch b.Output.Format {
+	case "csv":
+		return r.outputCSV(b)
+	case "ndjson", "jsonl":
+		return r.outputNDJSON(b, body)
+	default:
+		return fmt.Errorf("unsupported output format %q", b.Output.Format)
+	}
+}
+
+// outputCSV writes the records collected during the execution of a
+// block as CSV rows.
+func (r *Runner) outputCSV(b parse.Block) error {
+	if err := r.keepRecords(b); err != nil {
+		return err
+	}
+
+	w, err := r.csvOutput(b)
+	if err != nil {
+		return errors.Wrap(err, "opening csv output")
+	}
+	return w.Write(r.records)
+}
+
+// outputNDJSON validates each of the JSON documents rendered by a block
+// and writes them one per line.  Top-level fields of object documents
+// are made available to later blocks.
+func (r *Runner) outputNDJSON(b parse.Block, body string) error {
+	docs := []json.RawMessage{}
+
+	dec := json.NewDecoder(strings.NewReader(body))
+	for {
+		var doc json.RawMessage
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "invalid json document %d", len(docs)+1)
+		}
+		docs = append(docs, doc)
+	}
+
+	for _, doc := range docs {
+		obj := map[string]interface{}{}
+		dec := json.NewDecoder(strings.NewReader(string(doc)))
+		dec.UseNumber()
+		if err := dec.Decode(&obj); err != nil {
+			// Not an object, so there are no fields to reference.
+			continue
+		}
+		if err := r.store.set(b.Name, obj); err != nil {
+			return errors.Wrap(err, "storing document")
+		}
+	}
+
+	w, ok := r.ndjsons[b.Output.Path]
+	if !ok {
+		var err error
+		if w, err = sink.NewNDJSONFile(b.Output.Path); err != nil {
+			return errors.Wrap(err, "opening ndjson output")
+		}
+		r.ndjsons[b.Output.Path] = w
+	}
+
+	return w.Write(docs)
+}
+
+// jsonString returns a value as a quoted and escaped JSON string.
+func jsonString(v interface{}) (string, error) {
+	b, err := json.Marshal(fmt.Sprintf("%v", v))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// csvOutput returns the CSV writer for a block's output path, creating
+// it on first use so that multiple blocks can append to the same file.
+func (r *Runner) csvOutput(b parse.Block) (*sink.CSV, error) {
+	if w, ok := r.csvs[b.Output.Path]; ok {
+		return w, nil
+	}
+
+	opts := sink.CSVOptions{
+		Null: b.Output.Options["null"],
+	}
+
+	if d, ok := b.Output.Options["delimiter"]; ok {
+		delimiter, err := parseDelimiter(d)
+		if err != nil {
+			return nil, err
+		}
+		opts.Delimiter = delimiter
+	}
+
+	header := true
+	if h, ok := b.Output.Options["header"]; ok {
+		var err error
+		if header, err = strconv.ParseBool(h); err != nil {
+			return nil, errors.Wrap(err, "parsing header option")
+		}
+	}
+	if header {
+		opts.Header = b.Columns
+	}
+
+	w, err := sink.NewCSVFile(b.Output.Path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	r.csvs[b.Output.Path] = w
+	return w, nil
+}
+
+// parseDelimiter converts a delimiter option into a single rune,
+// allowing for escaped characters like \t.
+func parseDelimiter(d string) (rune, error) {
+	if d == "tab" {
+		return '\t', nil
+	}
+
+	d = unescape(d)
+
+	if utf8.RuneCountInString(d) != 1 {
+		return 0, fmt.Errorf("delimiter %q must be a single character", d)
+	}
+
+	rn, _ := utf8.DecodeRuneInString(d)
+	return rn, nil
+}
+
+// unescape interprets Go escape sequences like \t and \n in an option
+// value, returning the value as-is if it can't be interpreted.
+func unescape(s string) string {
+	if unquoted, err := strconv.Unquote(`"` + s + `"`); err == nil {
+		return unquoted
+	}
+	return s
+}
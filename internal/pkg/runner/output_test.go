@@ -0,0 +1,167 @@
+package runner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestRunCSVOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "owners.csv")
+
+	r := mustNew(t, sink.NewWriter(&bytes.Buffer{}))
+
+	b := parse.Block{
+		Repeat:  1,
+		Name:    "owner",
+		Body:    `{{range $i, $e := ntimes 2}}{{record $i (set "a,b")}}{{end}}`,
+		Columns: []string{"id", "name"},
+		Output: &parse.Output{
+			Format:  "csv",
+			Path:    path,
+			Options: map[string]string{"delimiter": "|"},
+		},
+	}
+
+	// Running twice to ensure the header is only written once.
+	test.ErrorExists(t, false, r.Run(b))
+	test.ErrorExists(t, false, r.Run(b))
+	test.ErrorExists(t, false, r.Close())
+
+	act, err := ioutil.ReadFile(path)
+	test.ErrorExists(t, false, err)
+	test.Equals(t, "id|name\n0|a,b\n1|a,b\n0|a,b\n1|a,b\n", string(act))
+
+	// Records are available to later blocks.
+	name, err := r.store.reference("owner", "name")
+	test.ErrorExists(t, false, err)
+	test.Equals(t, "a,b", name)
+}
+
+func TestRunCSVOutputErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		b    parse.Block
+	}{
+		{
+			name: "column count mismatch",
+			b: parse.Block{
+				Body:    `{{record 1 2}}`,
+				Columns: []string{"id"},
+				Output:  &parse.Output{Format: "csv", Path: "-"},
+			},
+		},
+		{
+			name: "unsupported format",
+			b: parse.Block{
+				Body:   `{{record 1}}`,
+				Output: &parse.Output{Format: "xml", Path: "-"},
+			},
+		},
+		{
+			name: "invalid delimiter",
+			b: parse.Block{
+				Body:   `{{record 1}}`,
+				Output: &parse.Output{Format: "csv", Path: "-", Options: map[string]string{"delimiter": ";;"}},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := mustNew(t, sink.NewWriter(&bytes.Buffer{}))
+			test.ErrorExists(t, true, r.Run(c.b))
+		})
+	}
+}
+
+func TestParseDelimiter(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		exp      rune
+		expError bool
+	}{
+		{name: "comma", input: ",", exp: ','},
+		{name: "semicolon", input: ";", exp: ';'},
+		{name: "escaped tab", input: `\t`, exp: '\t'},
+		{name: "tab", input: "tab", exp: '\t'},
+		{name: "multiple characters", input: "ab", expError: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			act, err := parseDelimiter(c.input)
+			test.ErrorExists(t, c.expError, err)
+			test.Equals(t, c.exp, act)
+		})
+	}
+}
+
+func TestRunNDJSONOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	r := mustNew(t, sink.NewWriter(&bytes.Buffer{}))
+
+	b := parse.Block{
+		Repeat: 1,
+		Name:   "event",
+		Body: `{{range $i, $e := ntimes 2}}
+		{
+			"id": {{$i}},
+			"name": {{jsonstr (set "say \"hi\"")}}
+		}
+		{{end}}`,
+		Output: &parse.Output{Format: "ndjson", Path: path},
+	}
+
+	test.ErrorExists(t, false, r.Run(b))
+	test.ErrorExists(t, false, r.Close())
+
+	act, err := ioutil.ReadFile(path)
+	test.ErrorExists(t, false, err)
+	test.Equals(t, "{\"id\":0,\"name\":\"say \\\"hi\\\"\"}\n{\"id\":1,\"name\":\"say \\\"hi\\\"\"}\n", string(act))
+
+	// Object fields are available to later blocks.
+	name, err := r.store.reference("event", "name")
+	test.ErrorExists(t, false, err)
+	test.Equals(t, `say "hi"`, name)
+}
+
+func TestRunNDJSONOutputInvalid(t *testing.T) {
+	r := mustNew(t, sink.NewWriter(&bytes.Buffer{}))
+
+	b := parse.Block{
+		Body:   `{"id": 1}{"id": }`,
+		Output: &parse.Output{Format: "ndjson", Path: "-"},
+	}
+
+	test.ErrorExists(t, true, r.Run(b))
+}
+
+func TestJSONString(t *testing.T) {
+	cases := []struct {
+		name  string
+		value interface{}
+		exp   string
+	}{
+		{name: "plain", value: "hello", exp: `"hello"`},
+		{name: "quotes", value: `say "hi"`, exp: `"say \"hi\""`},
+		{name: "control characters", value: "a\nb\tc", exp: `"a\nb\tc"`},
+		{name: "backslash", value: `a\b`, exp: `"a\\b"`},
+		{name: "non-string", value: 123, exp: `"123"`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			act, err := jsonString(c.value)
+			test.ErrorExists(t, false, err)
+			test.Equals(t, c.exp, act)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/plugin"
+)
+
+// startPlugin launches a block's plugin, unless it's already running.
+func (r *Runner) startPlugin(p *parse.Plugin) error {
+	if _, ok := r.plugins[p.Name]; ok {
+		return nil
+	}
+
+	started, err := plugin.Start(p.Name, p.Command, r.pluginTimeout)
+	if err != nil {
+		return err
+	}
+
+	r.plugins[p.Name] = started
+	return nil
+}
+
+// addPluginFuncs makes each of the functions declared by a plugin
+// available to templates as <plugin>_<function>.
+func (r *Runner) addPluginFuncs(p *parse.Plugin) error {
+	for _, function := range p.Functions {
+		name := p.Name + "_" + function
+		if owner, ok := r.pluginFuncs[name]; ok && owner == p.Name {
+			continue
+		}
+		if _, ok := r.funcs[name]; ok {
+			return fmt.Errorf("plugin function %q collides with another function", name)
+		}
+
+		pluginName, function := p.Name, function
+		r.funcs[name] = func(args ...interface{}) (interface{}, error) {
+			return r.callPlugin(pluginName, function, args...)
+		}
+		r.pluginFuncs[name] = p.Name
+	}
+
+	return nil
+}
+
+// callPlugin calls a function in a running plugin.
+func (r *Runner) callPlugin(name, function string, args ...interface{}) (interface{}, error) {
+	p, ok := r.plugins[name]
+	if !ok {
+		return nil, fmt.Errorf("plugin %q not found", name)
+	}
+
+	for i, a := range args {
+		args[i] = unwrap(a)
+	}
+
+	return p.Call(function, args...)
+}
+
+// closePlugins stops all running plugins, returning the first error
+// encountered.
+func (r *Runner) closePlugins() error {
+	var firstErr error
+	for name, p := range r.plugins {
+		if err := p.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(r.plugins, name)
+	}
+
+	return firstErr
+}
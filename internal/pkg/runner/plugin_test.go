@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+// TestPluginProcess isn't a real test, it's used as a plugin process by
+// TestRunPlugin.
+func TestPluginProcess(t *testing.T) {
+	if os.Getenv("DATAGEN_PLUGIN_PROCESS") != "1" {
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID   uint64        `json:"id"`
+			Args []interface{} `json:"args"`
+		}
+		json.Unmarshal(scanner.Bytes(), &req)
+
+		b, _ := json.Marshal(map[string]interface{}{
+			"id":     req.ID,
+			"result": fmt.Sprintf("SKU-%s", strings.ToUpper(fmt.Sprint(req.Args...))),
+		})
+		fmt.Println(string(b))
+	}
+	os.Exit(0)
+}
+
+func TestRunPlugin(t *testing.T) {
+	t.Setenv("DATAGEN_PLUGIN_PROCESS", "1")
+
+	buf := &bytes.Buffer{}
+	r := mustNew(t, sink.NewWriter(buf), WithPluginTimeout(time.Second))
+
+	blocks := []parse.Block{
+		{Plugin: &parse.Plugin{Name: "skus", Functions: []string{"sku"}, Command: []string{os.Args[0], "-test.run=TestPluginProcess"}}},
+		{Body: `{{plugin "skus" "sku" "abc"}}`},
+		{Body: `{{plugin "missing" "sku" "abc"}}`},
+		{Body: `{{skus_sku "def"}}`},
+	}
+
+	test.ErrorExists(t, false, r.Run(blocks[0]))
+	test.ErrorExists(t, false, r.Run(blocks[0]))
+	test.Equals(t, 1, len(r.plugins))
+
+	test.ErrorExists(t, false, r.Run(blocks[1]))
+	test.ErrorExists(t, true, r.Run(blocks[2]))
+	test.ErrorExists(t, false, r.Run(blocks[3]))
+
+	test.ErrorExists(t, false, r.Close())
+	test.Equals(t, "SKU-ABC;\nSKU-DEF;\n", buf.String())
+	test.Equals(t, 0, len(r.plugins))
+}
+
+func TestValidatePluginFuncs(t *testing.T) {
+	cases := []struct {
+		name     string
+		blocks   []parse.Block
+		expError bool
+	}{
+		{
+			name: "declared function",
+			blocks: []parse.Block{
+				{Plugin: &parse.Plugin{Name: "skus", Functions: []string{"sku"}, Command: []string{"./gen-sku.py"}}},
+				{Body: `{{skus_sku "abc"}}`},
+			},
+		},
+		{
+			name: "undeclared function",
+			blocks: []parse.Block{
+				{Plugin: &parse.Plugin{Name: "skus", Functions: []string{"sku"}, Command: []string{"./gen-sku.py"}}},
+				{Body: `{{skus_barcode "abc"}}`},
+			},
+			expError: true,
+		},
+		{
+			name: "collides with another plugin's function",
+			blocks: []parse.Block{
+				{Plugin: &parse.Plugin{Name: "a_b", Functions: []string{"c"}, Command: []string{"./a.py"}}},
+				{Plugin: &parse.Plugin{Name: "a", Functions: []string{"b_c"}, Command: []string{"./b.py"}}},
+			},
+			expError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := mustNew(t, sink.NewWriter(&bytes.Buffer{}))
+			test.ErrorExists(t, c.expError, r.Validate(c.blocks))
+		})
+	}
+}
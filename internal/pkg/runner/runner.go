@@ -2,19 +2,24 @@ package runner
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
 	"io/ioutil"
 	"reflect"
+	"sort"
 	"strings"
 	"text/template"
 	"time"
 
+	"github.com/codingconcepts/datagen/internal/pkg/locale"
 	"github.com/codingconcepts/datagen/internal/pkg/random"
 
 	"github.com/google/uuid"
 
 	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/plugin"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
 	"github.com/pkg/errors"
 
 	"github.com/Pallinder/go-randomdata"
@@ -22,13 +27,47 @@ import (
 
 // Runner holds the configuration that will be used at runtime.
 type Runner struct {
-	db           *sql.DB
+	sink         sink.Sink
 	funcs        template.FuncMap
+	customFuncs  map[string]customFunc
 	helpers      map[string]interface{}
 	store        *store
-	debug        bool
 	queryErrFile string
 
+	records   [][]interface{}
+	pending   pendingRecords
+	batchSize int
+	progress  func(rows int)
+
+	csvs    map[string]*sink.CSV
+	ndjsons map[string]*sink.NDJSON
+
+	plugins       map[string]*plugin.Plugin
+	pluginFuncs   map[string]string
+	pluginTimeout time.Duration
+
+	serials map[string]int64
+
+	sequences    *sequences
+	sequenceFile string
+
+	timeSeries map[string]*timeSeries
+
+	schemas map[string]*jsonSchema
+
+	uniques          map[string]uniqueSet
+	uniqueAttempts   int
+	uniqueFilterSize int
+	uniqueFilterRate float64
+
+	diskStore    bool
+	diskStoreDir string
+
+	localeCode string
+	locale     *locale.Locale
+
+	driver string
+
 	dateFormat      string
 	stringFdefaults random.StringFDefaults
 
@@ -39,56 +78,128 @@ type Runner struct {
 	nouns      []string
 }
 
-// New returns a pointer to a newly configured Runner.  Optionally
-// taking a variable number of configuration options.
-func New(db *sql.DB, opts ...Option) *Runner {
+// customFunc is a template function provided by the user.
+type customFunc struct {
+	fn       interface{}
+	override bool
+}
+
+// New returns a pointer to a newly configured Runner that writes to
+// the given Sink.  Optionally taking a variable number of configuration
+// options.
+func New(s sink.Sink, opts ...Option) (*Runner, error) {
 	r := Runner{
-		db:           db,
+		sink:         s,
+		customFuncs:  map[string]customFunc{},
 		store:        newStore(),
-		debug:        false,
 		queryErrFile: "query_err.sql",
+		batchSize:    10000,
 		stringFdefaults: random.StringFDefaults{
 			StringMinDefault: 10,
 			StringMaxDefault: 10,
 			IntMinDefault:    10000,
 			IntMaxDefault:    99999,
 		},
-		fsets:      map[string][]string{},
-		wsets:      map[string]random.WeightedItems{},
-		adjectives: strings.Split(strings.ToLower(adjectives), ","),
-		nouns:      strings.Split(strings.ToLower(nouns), ","),
+		csvs:           map[string]*sink.CSV{},
+		ndjsons:        map[string]*sink.NDJSON{},
+		plugins:        map[string]*plugin.Plugin{},
+		pluginFuncs:    map[string]string{},
+		pluginTimeout:  time.Second * 10,
+		serials:        map[string]int64{},
+		uniques:        map[string]uniqueSet{},
+		timeSeries:     map[string]*timeSeries{},
+		schemas:        map[string]*jsonSchema{},
+		uniqueAttempts: 100,
+		fsets:          map[string][]string{},
+		wsets:          map[string]random.WeightedItems{},
+		adjectives:     strings.Split(strings.ToLower(adjectives), ","),
+		nouns:          strings.Split(strings.ToLower(nouns), ","),
 	}
 
 	for _, opt := range opts {
 		opt(&r)
 	}
 
+	if r.uniqueFilterSize > 0 && (r.uniqueFilterRate <= 0 || r.uniqueFilterRate >= 1) {
+		return nil, fmt.Errorf("bloom filter false positive rate must be between 0 and 1, got %v", r.uniqueFilterRate)
+	}
+
+	var err error
+	if r.sequences, err = newSequences(r.sequenceFile); err != nil {
+		return nil, err
+	}
+
+	if r.localeCode != "" {
+		if r.locale, err = locale.Get(r.localeCode); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.diskStore {
+		b, err := newDiskBackend(r.diskStoreDir)
+		if err != nil {
+			return nil, err
+		}
+		r.store = newStoreWithBackend(b)
+	}
+
 	r.funcs = template.FuncMap{
 		"string":   random.String,
 		"stringf":  random.StringF(r.stringFdefaults),
+		"regex":    random.Regex,
 		"int":      random.Int,
 		"date":     random.Date(r.dateFormat),
 		"float":    random.Float,
+		"normal":   random.Normal,
+		"lognorm":  random.LogNormal,
+		"expon":    random.Exponential,
+		"poisson":  random.Poisson,
+		"pareto":   random.Pareto,
+		"zipf":     random.Zipf,
+		"series":   r.series,
 		"ntimes":   random.NTimes,
 		"set":      random.Set,
 		"uuid":     func() string { return uuid.New().String() },
+		"card":     random.Card,
+		"iban":     random.IBAN,
+		"isbn10":   random.ISBN10,
+		"isbn13":   random.ISBN13,
+		"ean13":    random.EAN13,
+		"routing":  random.RoutingNumber,
 		"wset":     r.wset,
 		"fset":     r.loadAndSet,
 		"ref":      r.store.reference,
+		"refz":     r.store.referenceZipf,
+		"refh":     r.store.referenceHot,
+		"refw":     r.store.referenceWeighted,
 		"row":      r.store.row,
 		"each":     r.store.each,
+		"record":   r.record,
+		"nullable": r.nullable,
+		"jsonstr":  jsonString,
+		"json":     r.jsonSQL,
+		"jobj":     jsonObj,
+		"jarr":     jsonArr,
+		"jarrn":    r.jsonArrN,
+		"jopt":     jsonOpt,
+		"jschema":  r.jsonFromSchema,
+		"plugin":   r.callPlugin,
+		"unique":   r.unique,
+		"seq":      r.sequences.next,
+		"seqf":     r.sequences.nextf,
 		"adj":      func() string { return r.adjectives[random.Int(0, int64(len(r.adjectives)-1))] },
 		"noun":     func() string { return r.nouns[random.Int(0, int64(len(r.nouns)-1))] },
 		"title":    func() string { return randomdata.Title(randomdata.RandomGender) },
-		"namef":    func() string { return randomdata.FirstName(randomdata.RandomGender) },
-		"namel":    randomdata.LastName,
-		"name":     func() string { return randomdata.FullName(randomdata.RandomGender) },
+		"namef":    r.localised(func() string { return randomdata.FirstName(randomdata.RandomGender) }, (*locale.Locale).FirstName),
+		"namel":    r.localised(randomdata.LastName, (*locale.Locale).LastName),
+		"name":     r.localised(func() string { return randomdata.FullName(randomdata.RandomGender) }, (*locale.Locale).Name),
 		"email":    randomdata.Email,
-		"phone":    randomdata.PhoneNumber,
-		"postcode": randomdata.PostalCode,
-		"address":  randomdata.Address,
-		"street":   randomdata.StreetForCountry,
-		"city":     randomdata.City,
+		"phone":    r.localised(randomdata.PhoneNumber, (*locale.Locale).Phone),
+		"postcode": r.postcode,
+		"address":  r.address,
+		"person":   r.person,
+		"street":   r.street,
+		"city":     r.localised(randomdata.City, func(l *locale.Locale) string { return l.City().Name }),
 		"county":   randomdata.ProvinceForCountry,
 		"state":    func() string { return randomdata.State(randomdata.Large) },
 		"state2":   func() string { return randomdata.State(randomdata.Small) },
@@ -102,73 +213,259 @@ func New(db *sql.DB, opts ...Option) *Runner {
 		"agent":    randomdata.UserAgentString,
 	}
 
-	return &r
+	if err := r.addCustomFuncs(); err != nil {
+		r.store.close()
+		return nil, err
+	}
+
+	return &r, nil
+}
+
+// addCustomFuncs merges the user's template functions with the built-in
+// functions, failing if a function would replace a built-in function
+// without being explicitly allowed to.
+func (r *Runner) addCustomFuncs() error {
+	for name, cf := range r.customFuncs {
+		if _, ok := r.funcs[name]; ok && !cf.override {
+			return fmt.Errorf("function %q collides with a built-in function", name)
+		}
+
+		if err := validFunc(cf.fn); err != nil {
+			return errors.Wrapf(err, "invalid function %q", name)
+		}
+
+		r.funcs[name] = cf.fn
+	}
+
+	return nil
+}
+
+// validFunc ensures that a value can be called from a template, which
+// requires it to be a function returning a single value and an optional
+// error.
+func validFunc(fn interface{}) error {
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func {
+		return fmt.Errorf("%T is not a function", fn)
+	}
+
+	switch {
+	case t.NumOut() == 1:
+		return nil
+	case t.NumOut() == 2 && t.Out(1) == reflect.TypeOf((*error)(nil)).Elem():
+		return nil
+	default:
+		return errors.New("functions must return a single value and an optional error")
+	}
+}
+
+// Funcs returns the sorted names of all of the functions available to
+// templates, including any provided by the user.
+func (r *Runner) Funcs() []string {
+	names := make([]string, 0, len(r.funcs))
+	for name := range r.funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Validate parses the template of each block without executing it,
+// returning the first error encountered.
+func (r *Runner) Validate(blocks []parse.Block) error {
+	for i, b := range blocks {
+		if b.Plugin != nil {
+			if err := r.addPluginFuncs(b.Plugin); err != nil {
+				return errors.Wrapf(err, "parsing block %d %q", i+1, b.Name)
+			}
+		}
+
+		if _, err := template.New("block").Funcs(r.funcs).Parse(b.Body); err != nil {
+			return errors.Wrapf(err, "parsing block %d %q", i+1, b.Name)
+		}
+	}
+
+	return nil
 }
 
 // Run executes a given block, returning any errors encountered.
 func (r *Runner) Run(b parse.Block) error {
+	return r.RunContext(context.Background(), b)
+}
+
+// RunContext executes a given block using the given context, returning
+// any errors encountered.
+func (r *Runner) RunContext(ctx context.Context, b parse.Block) error {
+	if b.Plugin != nil {
+		if err := r.addPluginFuncs(b.Plugin); err != nil {
+			return err
+		}
+		if err := r.startPlugin(b.Plugin); err != nil {
+			return errors.Wrap(err, "starting plugin")
+		}
+
+		// Blocks that only declare a plugin have nothing to execute.
+		if strings.TrimSpace(b.Body) == "" {
+			return nil
+		}
+	}
+
+	if b.Keep != nil {
+		r.store.keep(b.Name, *b.Keep)
+	}
+
 	tmpl, err := template.New("block").Funcs(r.funcs).Parse(b.Body)
 	if err != nil {
 		return errors.Wrap(err, "parsing template")
 	}
 
+	r.records = nil
+	r.store.nextEach()
 	buf := &bytes.Buffer{}
 	if err := tmpl.Execute(buf, r.helpers); err != nil {
 		return errors.Wrap(err, "executing template")
 	}
 
-	if r.debug {
-		fmt.Println(buf.String())
-		return nil
+	if b.Output != nil {
+		return errors.Wrap(r.output(b, buf.String()), "writing output")
+	}
+
+	if b.Copy != nil {
+		return r.copy(ctx, b)
+	}
+
+	if b.Infile != nil {
+		return r.infile(ctx, b)
+	}
+
+	if b.Load != nil {
+		return r.load(ctx, b, buf.String())
 	}
 
-	rows, err := r.db.Query(buf.String())
+	rows, err := r.sink.Query(ctx, buf.String())
 	if err != nil {
 		r.mustDumpQuery(buf.Bytes())
 		return errors.Wrap(err, "executing query")
 	}
 
+	// Sinks that don't talk to a database have nothing to return, so
+	// simulate the rows the database would have returned.
+	if rows == nil {
+		return r.simulate(b, buf.String())
+	}
+	defer rows.Close()
+
 	return r.scan(b, rows)
 }
 
-// ResetEach resets the variables used for keeping track of sequential row
-// references of previous block results.
+// Close flushes any buffered records, stops any plugins and closes the
+// Runner's Sink, store and any block outputs.
+func (r *Runner) Close() (err error) {
+	defer func() {
+		if serr := r.store.close(); err == nil && serr != nil {
+			err = errors.Wrap(serr, "closing store")
+		}
+	}()
+
+	// Sequences are saved even if closing fails, so the values they've
+	// already handed out aren't handed out again by the next run.
+	defer func() {
+		if serr := r.sequences.save(); err == nil && serr != nil {
+			err = serr
+		}
+	}()
+
+	if err := r.Flush(); err != nil {
+		return err
+	}
+
+	if err := r.closePlugins(); err != nil {
+		return err
+	}
+
+	for path, w := range r.csvs {
+		if err := w.Close(); err != nil {
+			return errors.Wrapf(err, "closing %q", path)
+		}
+	}
+	for path, w := range r.ndjsons {
+		if err := w.Close(); err != nil {
+			return errors.Wrapf(err, "closing %q", path)
+		}
+	}
+
+	return r.sink.Close()
+}
+
+// Results returns the rows returned by, or recorded in, each named block
+// that has been run.  When using a disk store, Results must be called
+// before the Runner is closed.
+func (r *Runner) Results() (map[string][]map[string]interface{}, error) {
+	return r.store.rows(unwrap)
+}
+
+// ResetEach resets the cursors used by the named block to keep track of
+// sequential row references of previous block results.  Cursors used by
+// other blocks are unaffected.
 func (r *Runner) ResetEach(name string) {
-	r.store.eachRow = 0
-	r.store.currentGroup = 0
-	r.store.eachContext = name
+	r.store.resetEach(name)
 }
 
 func (r *Runner) scan(b parse.Block, rows *sql.Rows) error {
 	for rows.Next() {
-		columnTypes, err := rows.ColumnTypes()
+		curr, err := r.scanRow(rows)
 		if err != nil {
-			return errors.Wrap(err, "getting columns types from result")
+			return err
 		}
-
-		values := make([]interface{}, len(columnTypes))
-		for i, ct := range columnTypes {
-			switch ct.DatabaseTypeName() {
-			case "UUID":
-				values[i] = reflect.New(reflect.TypeOf("")).Interface()
-			default:
-				values[i] = reflect.New(ct.ScanType()).Interface()
-			}
+		if err = r.store.set(b.Name, curr); err != nil {
+			return errors.Wrap(err, "storing row")
 		}
+	}
 
-		if err = rows.Scan(values...); err != nil {
-			return errors.Wrap(err, "scanning columns")
-		}
+	return rows.Err()
+}
 
-		curr := map[string]interface{}{}
-		for i, ct := range columnTypes {
-			values[i] = r.prepareValue(reflect.ValueOf(values[i]).Elem())
-			curr[ct.Name()] = values[i]
-		}
-		r.store.set(b.Name, curr)
+// scanRow scans the current row into a map of column names to values.
+func (r *Runner) scanRow(rows *sql.Rows) (map[string]interface{}, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting columns types from result")
 	}
 
-	return nil
+	values := make([]interface{}, len(columnTypes))
+	for i, ct := range columnTypes {
+		values[i] = scanTarget(ct)
+	}
+
+	if err = rows.Scan(values...); err != nil {
+		return nil, errors.Wrap(err, "scanning columns")
+	}
+
+	curr := map[string]interface{}{}
+	for i, ct := range columnTypes {
+		values[i] = r.prepareValue(reflect.ValueOf(values[i]).Elem())
+		curr[ct.Name()] = values[i]
+	}
+
+	return curr, nil
+}
+
+// scanTarget returns a pointer to a value that a column can be scanned
+// into.  Loosely typed databases like SQLite derive the scan type from
+// each value's storage class, which may be NULL or a type that can't be
+// scanned into, so these columns are scanned into an interface{}.
+func scanTarget(ct *sql.ColumnType) interface{} {
+	if ct.DatabaseTypeName() == "UUID" {
+		return reflect.New(reflect.TypeOf("")).Interface()
+	}
+
+	t := ct.ScanType()
+	if t == nil || (t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Slice) {
+		return new(interface{})
+	}
+
+	return reflect.New(t).Interface()
 }
 
 // prepareValue ensures that data being read out of the database following
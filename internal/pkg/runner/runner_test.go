@@ -1,13 +1,17 @@
 package runner
 
 import (
+	"bytes"
 	"database/sql/driver"
 	"reflect"
+	"sort"
 	"testing"
+	"text/template"
 	"time"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
 	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
 	"github.com/codingconcepts/datagen/internal/pkg/test"
 )
 
@@ -48,7 +52,7 @@ func TestRun(t *testing.T) {
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			resetMock()
-			r := New(db)
+			r := mustNew(t, sink.NewDatabase(db))
 
 			id, name, dob := 123, "Alice", time.Date(2019, time.January, 2, 3, 4, 5, 0, time.UTC)
 
@@ -84,8 +88,23 @@ func TestRun(t *testing.T) {
 	}
 }
 
+func TestRunWriterSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := mustNew(t, sink.NewWriter(buf))
+
+	b := parse.Block{
+		Repeat: 1,
+		Name:   "owner",
+		Body:   `insert into "owner" ("name") values ('{{set "Alice"}}')`,
+	}
+
+	test.ErrorExists(t, false, r.Run(b))
+	test.ErrorExists(t, false, r.Close())
+	test.Equals(t, "insert into \"owner\" (\"name\") values ('Alice');\n", buf.String())
+}
+
 func TestPrepareValue(t *testing.T) {
-	r := New(db, WithDateFormat("20060102"))
+	r := mustNew(t, sink.NewDatabase(db), WithDateFormat("20060102"))
 
 	cases := []struct {
 		name  string
@@ -111,3 +130,47 @@ func TestPrepareValue(t *testing.T) {
 		})
 	}
 }
+
+func TestFuncs(t *testing.T) {
+	r := mustNew(t, nil, WithFuncs(template.FuncMap{"sku": func() string { return "" }}))
+
+	funcs := r.Funcs()
+	test.Assert(t, sort.StringsAreSorted(funcs))
+
+	found := map[string]bool{}
+	for _, f := range funcs {
+		found[f] = true
+	}
+	test.Assert(t, found["sku"])
+	test.Assert(t, found["uuid"])
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name     string
+		blocks   []parse.Block
+		expError bool
+	}{
+		{
+			name:   "valid blocks",
+			blocks: []parse.Block{{Body: `{{uuid}}`}, {Body: `{{sku}}`}},
+		},
+		{
+			name:     "unknown function",
+			blocks:   []parse.Block{{Body: `{{uuid}}`}, {Body: `{{missing}}`}},
+			expError: true,
+		},
+		{
+			name:     "invalid template",
+			blocks:   []parse.Block{{Body: `{{range $i, $e := ntimes 10 }}`}},
+			expError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := mustNew(t, nil, WithFuncs(template.FuncMap{"sku": func() string { return "" }}))
+			test.ErrorExists(t, c.expError, r.Validate(c.blocks))
+		})
+	}
+}
@@ -0,0 +1,379 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Pallinder/go-randomdata"
+	"github.com/codingconcepts/datagen/internal/pkg/random"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// jsonSchema is the subset of JSON Schema that documents can be generated
+// from.
+type jsonSchema struct {
+	Type       schemaTypes      `json:"type"`
+	Properties schemaProperties `json:"properties"`
+	Required   []string         `json:"required"`
+	Items      *jsonSchema      `json:"items"`
+	MinItems   *int             `json:"minItems"`
+	MaxItems   *int             `json:"maxItems"`
+	Enum       []interface{}    `json:"enum"`
+	Const      json.RawMessage  `json:"const"`
+	Format     string           `json:"format"`
+	Pattern    string           `json:"pattern"`
+	MinLength  *int             `json:"minLength"`
+	MaxLength  *int             `json:"maxLength"`
+	Minimum    *json.Number     `json:"minimum"`
+	Maximum    *json.Number     `json:"maximum"`
+}
+
+// schemaTypes holds a schema's types, which can be given as a single type
+// or an array of them.
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(b, &multiple); err != nil {
+		return errors.Wrap(err, "parsing type")
+	}
+	*t = multiple
+	return nil
+}
+
+// schemaProperties holds an object's properties in the order they're
+// written in the schema, so that generated objects keep it.
+type schemaProperties []schemaProperty
+
+type schemaProperty struct {
+	name   string
+	schema *jsonSchema
+}
+
+func (p *schemaProperties) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if _, err := dec.Token(); err != nil {
+		return errors.Wrap(err, "parsing properties")
+	}
+
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return errors.Wrap(err, "parsing properties")
+		}
+		name, ok := t.(string)
+		if !ok {
+			return fmt.Errorf("expected a property name, got %v", t)
+		}
+
+		var s jsonSchema
+		if err = dec.Decode(&s); err != nil {
+			return errors.Wrapf(err, "parsing property %q", name)
+		}
+		*p = append(*p, schemaProperty{name: name, schema: &s})
+	}
+
+	return nil
+}
+
+// jsonFromSchema returns a value generated from a JSON Schema, given
+// either inline or as the path of a file containing one.
+func (r *Runner) jsonFromSchema(schema string) (interface{}, error) {
+	s, ok := r.schemas[schema]
+	if !ok {
+		b := []byte(schema)
+		if !strings.HasPrefix(strings.TrimSpace(schema), "{") {
+			var err error
+			if b, err = ioutil.ReadFile(schema); err != nil {
+				return nil, errors.Wrap(err, "reading schema")
+			}
+		}
+
+		s = &jsonSchema{}
+		if err := json.Unmarshal(b, s); err != nil {
+			return nil, errors.Wrap(err, "parsing schema")
+		}
+		r.schemas[schema] = s
+	}
+
+	return s.generate()
+}
+
+func (s *jsonSchema) generate() (interface{}, error) {
+	// The const is kept raw until it's needed, so that a const of null
+	// can be told apart from a missing one.
+	if len(s.Const) > 0 {
+		var v interface{}
+		if err := json.Unmarshal(s.Const, &v); err != nil {
+			return nil, errors.Wrap(err, "parsing const")
+		}
+		return v, nil
+	}
+	if len(s.Enum) > 0 {
+		return s.Enum[rand.Intn(len(s.Enum))], nil
+	}
+
+	var t string
+	switch {
+	case len(s.Type) > 0:
+		t = s.Type[rand.Intn(len(s.Type))]
+	case s.Properties != nil:
+		t = "object"
+	case s.Items != nil:
+		t = "array"
+	default:
+		return nil, fmt.Errorf("schema has no type")
+	}
+
+	switch t {
+	case "object":
+		return s.generateObject()
+	case "array":
+		return s.generateArray()
+	case "string":
+		return s.generateString()
+	case "integer":
+		min, max, err := s.intBounds()
+		if err != nil {
+			return nil, err
+		}
+		return randomInt64(min, max), nil
+	case "number":
+		min, max, err := s.bounds()
+		if err != nil {
+			return nil, err
+		}
+		return min + rand.Float64()*(max-min), nil
+	case "boolean":
+		return rand.Intn(2) == 0, nil
+	case "null":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported schema type %q", t)
+	}
+}
+
+// generateObject returns an object with all of its required properties
+// and half of its other properties.
+func (s *jsonSchema) generateObject() (interface{}, error) {
+	required := map[string]bool{}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+
+	o := jsonObject{}
+	for _, p := range s.Properties {
+		if !required[p.name] && rand.Intn(2) == 0 {
+			continue
+		}
+
+		v, err := p.schema.generate()
+		if err != nil {
+			return nil, errors.Wrapf(err, "generating %q", p.name)
+		}
+		o = append(o, jsonField{key: p.name, value: v})
+	}
+
+	return o, nil
+}
+
+func (s *jsonSchema) generateArray() (interface{}, error) {
+	if s.Items == nil {
+		return nil, fmt.Errorf("array schema has no items")
+	}
+
+	min, max := lengthRange(s.MinItems, s.MaxItems, 0, 5)
+	if min < 0 || max < min {
+		return nil, fmt.Errorf("invalid array length range %d to %d", min, max)
+	}
+
+	a := make(jsonArray, min+rand.Intn(max-min+1))
+	for i := range a {
+		v, err := s.Items.generate()
+		if err != nil {
+			return nil, errors.Wrap(err, "generating item")
+		}
+		a[i] = v
+	}
+
+	return a, nil
+}
+
+func (s *jsonSchema) generateString() (interface{}, error) {
+	if s.Pattern != "" {
+		return random.Regex(s.Pattern)
+	}
+
+	switch s.Format {
+	case "email":
+		return randomdata.Email(), nil
+	case "uuid":
+		return uuid.New().String(), nil
+	case "date":
+		return randomTime().Format("2006-01-02"), nil
+	case "date-time":
+		return randomTime().Format(time.RFC3339), nil
+	case "ipv4":
+		return randomdata.IpV4Address(), nil
+	case "ipv6":
+		return randomdata.IpV6Address(), nil
+	case "uri":
+		return "https://example.com/" + random.String(5, 10, "abcdefghijklmnopqrstuvwxyz"), nil
+	}
+
+	min, max := lengthRange(s.MinLength, s.MaxLength, 5, 10)
+	if min < 0 || max < min {
+		return nil, fmt.Errorf("invalid string length range %d to %d", min, max)
+	}
+
+	n := int64(min + rand.Intn(max-min+1))
+	return random.String(n, n, ""), nil
+}
+
+// lengthRange returns the minimum and maximum length of a string or
+// array.  Without a maximum, lengths range up to spread more than the
+// minimum, which defaults to def, or the maximum if that's smaller.
+func lengthRange(minLength, maxLength *int, def, spread int) (int, int) {
+	min := def
+	if minLength != nil {
+		min = *minLength
+	}
+
+	max := min + spread
+	if maxLength != nil {
+		max = *maxLength
+		if minLength == nil && min > max {
+			min = max
+		}
+	}
+
+	return min, max
+}
+
+// bounds returns the minimum and maximum of a number, which default to a
+// range of 1000.
+func (s *jsonSchema) bounds() (float64, float64, error) {
+	var min, max float64
+	var err error
+	if s.Minimum != nil {
+		if min, err = s.Minimum.Float64(); err != nil {
+			return 0, 0, errors.Wrap(err, "parsing minimum")
+		}
+	}
+	if s.Maximum != nil {
+		if max, err = s.Maximum.Float64(); err != nil {
+			return 0, 0, errors.Wrap(err, "parsing maximum")
+		}
+	}
+
+	switch {
+	case s.Minimum != nil && s.Maximum != nil:
+	case s.Minimum != nil:
+		max = min + 1000
+	case s.Maximum != nil:
+		min = max - 1000
+	default:
+		max = 1000
+	}
+
+	if max < min {
+		return 0, 0, fmt.Errorf("minimum %v is greater than maximum %v", min, max)
+	}
+	return min, max, nil
+}
+
+// intBounds returns the minimum and maximum of an integer, which default
+// to a range of 1000.  Bounds are parsed as integers, so that they aren't
+// rounded like large numbers are as floats.
+func (s *jsonSchema) intBounds() (int64, int64, error) {
+	var min, max int64
+	var err error
+	if s.Minimum != nil {
+		if min, err = intBound(*s.Minimum, math.Ceil); err != nil {
+			return 0, 0, errors.Wrap(err, "parsing minimum")
+		}
+	}
+	if s.Maximum != nil {
+		if max, err = intBound(*s.Maximum, math.Floor); err != nil {
+			return 0, 0, errors.Wrap(err, "parsing maximum")
+		}
+	}
+
+	switch {
+	case s.Minimum != nil && s.Maximum != nil:
+	case s.Minimum != nil:
+		max = min + 1000
+		if max < min {
+			max = math.MaxInt64
+		}
+	case s.Maximum != nil:
+		min = max - 1000
+		if min > max {
+			min = math.MinInt64
+		}
+	default:
+		max = 1000
+	}
+
+	if max < min {
+		return 0, 0, fmt.Errorf("no integers between %v and %v", *s.Minimum, *s.Maximum)
+	}
+	return min, max, nil
+}
+
+// intBound parses an integer bound, rounding fractional bounds to the
+// nearest integer within them and clamping bounds beyond int64's range.
+func intBound(n json.Number, round func(float64) float64) (int64, error) {
+	if i, err := n.Int64(); err == nil {
+		return i, nil
+	}
+
+	f, err := n.Float64()
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, err
+	}
+
+	switch f = round(f); {
+	case f >= math.MaxInt64:
+		return math.MaxInt64, nil
+	case f <= math.MinInt64:
+		return math.MinInt64, nil
+	default:
+		return int64(f), nil
+	}
+}
+
+// randomInt64 returns a random integer between min and max inclusive,
+// which may span the whole range of int64.
+func randomInt64(min, max int64) int64 {
+	span := uint64(max - min)
+	if span < math.MaxInt64 {
+		return min + rand.Int63n(int64(span)+1)
+	}
+
+	for {
+		if v := rand.Uint64(); v <= span {
+			return min + int64(v)
+		}
+	}
+}
+
+// randomTime returns a random time within the last five years.
+func randomTime() time.Time {
+	now := time.Now().UTC()
+	return now.Add(-time.Duration(rand.Int63n(int64(5 * 365 * 24 * time.Hour)))).Truncate(time.Second)
+}
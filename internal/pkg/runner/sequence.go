@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// sequences holds the last value returned by each named sequence,
+// optionally persisting them to a file so that subsequent runs continue
+// where the last left off.
+type sequences struct {
+	mu   sync.Mutex
+	last map[string]int64
+	path string
+}
+
+// newSequences returns a pointer to a sequences that loads and saves its
+// state at path.  An empty path disables persistence, as does a path
+// that doesn't exist yet, until the state is saved.
+func newSequences(path string) (*sequences, error) {
+	s := sequences{
+		last: map[string]int64{},
+		path: path,
+	}
+
+	if path == "" {
+		return &s, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &s, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading sequence file")
+	}
+
+	if err = json.Unmarshal(b, &s.last); err != nil {
+		return nil, errors.Wrap(err, "parsing sequence file")
+	}
+
+	return &s, nil
+}
+
+// next returns the next value of a sequence.  The start and step are
+// optional and default to 1.  A sequence starts at start and increases by
+// step, unless it was continued from a previous run.
+func (s *sequences) next(name string, args ...int64) (int64, error) {
+	start, step := int64(1), int64(1)
+	switch len(args) {
+	case 0:
+	case 1:
+		start = args[0]
+	case 2:
+		start, step = args[0], args[1]
+	default:
+		return 0, fmt.Errorf("expected an optional start and step, got %d args", len(args))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, ok := s.last[name]
+	if ok {
+		n += step
+	} else {
+		n = start
+	}
+
+	s.last[name] = n
+	return n, nil
+}
+
+// nextf returns the next value of a sequence, formatted with a format
+// string (e.g. "INV-%06d").
+func (s *sequences) nextf(name, format string, args ...int64) (string, error) {
+	n, err := s.next(name, args...)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf(format, n), nil
+}
+
+// save writes the last value of each sequence to the sequence file, if
+// there is one.  The file is replaced atomically, so a failed write
+// doesn't lose the previous state.
+func (s *sequences) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := json.MarshalIndent(s.last, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encoding sequences")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return errors.Wrap(err, "creating sequence file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "writing sequence file")
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Wrap(err, "writing sequence file")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), s.path), "replacing sequence file")
+}
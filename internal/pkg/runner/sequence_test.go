@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestSequenceNext(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []int64
+		exp      []int64
+		expError bool
+	}{
+		{name: "defaults", exp: []int64{1, 2, 3}},
+		{name: "start", args: []int64{1000}, exp: []int64{1000, 1001, 1002}},
+		{name: "start and step", args: []int64{10, -5}, exp: []int64{10, 5, 0}},
+		{name: "too many args", args: []int64{1, 2, 3}, expError: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s, err := newSequences("")
+			test.ErrorExists(t, false, err)
+
+			act := []int64{}
+			for i := 0; i < len(c.exp) || (c.expError && i == 0); i++ {
+				n, err := s.next("order_no", c.args...)
+				test.ErrorExists(t, c.expError, err)
+				if err != nil {
+					return
+				}
+				act = append(act, n)
+			}
+			test.Equals(t, c.exp, act)
+		})
+	}
+}
+
+func TestSequenceNextConcurrent(t *testing.T) {
+	s, err := newSequences("")
+	test.ErrorExists(t, false, err)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	act := []int{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				n, _ := s.next("order_no")
+				mu.Lock()
+				act = append(act, int(n))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Ints(act)
+	for i, n := range act {
+		test.Equals(t, i+1, n)
+	}
+}
+
+func TestSequencePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sequences.json")
+
+	run := func() string {
+		buf := &bytes.Buffer{}
+		r := mustNew(t, sink.NewWriter(buf), WithSequenceFile(path))
+
+		b := parse.Block{Body: `{{range $i, $e := ntimes 3}}{{seqf "invoice" "INV-%06d" 100 10}} {{end}}`}
+		test.ErrorExists(t, false, r.Run(b))
+		test.ErrorExists(t, false, r.Close())
+
+		return buf.String()
+	}
+
+	test.Equals(t, "INV-000100 INV-000110 INV-000120;\n", run())
+	test.Equals(t, "INV-000130 INV-000140 INV-000150;\n", run())
+
+	// Only the sequence file is left behind.
+	files, err := os.ReadDir(filepath.Dir(path))
+	test.ErrorExists(t, false, err)
+	test.Equals(t, 1, len(files))
+}
+
+func TestSequenceSavedWhenCloseFails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sequences.json")
+
+	r := mustNew(t, struct{ sink.Sink }{sink.NewWriter(&bytes.Buffer{})}, WithSequenceFile(path))
+
+	// The sink can't copy, so flushing the recorded row fails on close.
+	b := parse.Block{Body: `{{record (seq "invoice" 100 10)}}`, Columns: []string{"id"}, Copy: &parse.Copy{Table: "invoice"}}
+	test.ErrorExists(t, false, r.Run(b))
+	test.ErrorExists(t, true, r.Close())
+
+	s, err := newSequences(path)
+	test.ErrorExists(t, false, err)
+	v, err := s.next("invoice", 100, 10)
+	test.ErrorExists(t, false, err)
+	test.Equals(t, int64(110), v)
+}
+
+func TestSequenceInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sequences.json")
+	test.ErrorExists(t, false, os.WriteFile(path, []byte("not json"), 0644))
+
+	_, err := New(nil, WithSequenceFile(path))
+	test.ErrorExists(t, true, err)
+}
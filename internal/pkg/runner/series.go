@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codingconcepts/datagen/internal/pkg/random"
+	"github.com/pkg/errors"
+)
+
+// Point is a point in a time series, returned to templates.
+type Point struct {
+	// Time of the point, formatted with the series' format.
+	Time string
+
+	// Unix time of the point in seconds.
+	Unix int64
+
+	// Value of the point.
+	Value float64
+}
+
+type timeSeries struct {
+	series *random.Series
+	format string
+}
+
+// series returns the next point of a named time series.  The series is
+// configured with key=value options the first time it's used, after which
+// options are ignored.
+func (r *Runner) series(name string, opts ...string) (Point, error) {
+	s, ok := r.timeSeries[name]
+	if !ok {
+		var err error
+		if s, err = r.newSeries(opts...); err != nil {
+			return Point{}, errors.Wrapf(err, "creating series %q", name)
+		}
+		r.timeSeries[name] = s
+	}
+
+	t, v := s.series.Next()
+	return Point{Time: t.Format(s.format), Unix: t.Unix(), Value: v}, nil
+}
+
+func (r *Runner) newSeries(opts ...string) (*timeSeries, error) {
+	s := timeSeries{format: time.RFC3339}
+	o := random.SeriesOptions{
+		Start:    time.Now().UTC().Truncate(time.Second),
+		Interval: time.Minute,
+	}
+
+	var start string
+	for _, opt := range opts {
+		kv := strings.SplitN(opt, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("expected key=value, got %q", opt)
+		}
+
+		var err error
+		switch kv[0] {
+		case "start":
+			start = kv[1]
+		case "format":
+			s.format = kv[1]
+		case "interval":
+			o.Interval, err = time.ParseDuration(kv[1])
+		case "jitter":
+			o.Jitter, err = time.ParseDuration(kv[1])
+		case "base":
+			o.Base, err = strconv.ParseFloat(kv[1], 64)
+		case "trend":
+			o.Trend, err = strconv.ParseFloat(kv[1], 64)
+		case "daily":
+			o.Daily, err = strconv.ParseFloat(kv[1], 64)
+		case "weekly":
+			o.Weekly, err = strconv.ParseFloat(kv[1], 64)
+		case "noise":
+			o.Noise, err = strconv.ParseFloat(kv[1], 64)
+		default:
+			return nil, fmt.Errorf("unknown option %q", kv[0])
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", kv[0])
+		}
+	}
+
+	// The start is parsed once the format is known.
+	if start != "" {
+		var err error
+		if o.Start, err = time.Parse(s.format, start); err != nil {
+			if o.Start, err = time.Parse(r.dateFormat, start); err != nil {
+				return nil, errors.Wrap(err, "parsing start")
+			}
+		}
+	}
+
+	var err error
+	if s.series, err = random.NewSeries(o); err != nil {
+		return nil, err
+	}
+
+	return &s, nil
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestSeries(t *testing.T) {
+	cases := []struct {
+		name     string
+		opts     []string
+		exp      []Point
+		expError bool
+	}{
+		{
+			name: "defaults with start",
+			opts: []string{"start=2024-01-01T00:00:00Z", "base=5"},
+			exp: []Point{
+				{Time: "2024-01-01T00:00:00Z", Unix: 1704067200, Value: 5},
+				{Time: "2024-01-01T00:01:00Z", Unix: 1704067260, Value: 5},
+			},
+		},
+		{
+			name: "format and interval",
+			opts: []string{"start=2024-01-01 10:00", "format=2006-01-02 15:04", "interval=1h", "base=1", "trend=24"},
+			exp: []Point{
+				{Time: "2024-01-01 10:00", Unix: 1704103200, Value: 1},
+				{Time: "2024-01-01 11:00", Unix: 1704106800, Value: 2},
+			},
+		},
+		{
+			name: "start in date format",
+			opts: []string{"start=2024-01-01", "interval=24h"},
+			exp: []Point{
+				{Time: "2024-01-01T00:00:00Z", Unix: 1704067200},
+				{Time: "2024-01-02T00:00:00Z", Unix: 1704153600},
+			},
+		},
+		{name: "invalid option", opts: []string{"base"}, expError: true},
+		{name: "unknown option", opts: []string{"colour=red"}, expError: true},
+		{name: "invalid value", opts: []string{"noise=loud"}, expError: true},
+		{name: "invalid start", opts: []string{"start=yesterday"}, expError: true},
+		{name: "invalid series", opts: []string{"interval=1m", "jitter=1m"}, expError: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := mustNew(t, nil, WithDateFormat("2006-01-02"))
+
+			for _, exp := range c.exp {
+				act, err := r.series("cpu", c.opts...)
+				test.ErrorExists(t, false, err)
+				test.Equals(t, exp, act)
+			}
+
+			if c.expError {
+				_, err := r.series("cpu", c.opts...)
+				test.ErrorExists(t, true, err)
+			}
+		})
+	}
+}
+
+func TestRunSeries(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := mustNew(t, sink.NewWriter(buf))
+
+	b := parse.Block{
+		Body: `{{range $i, $e := ntimes 2}}{{$p := series "cpu" "start=2024-01-01T00:00:00Z" "base=50"}}('{{$p.Time}}', {{$p.Value}}){{end}}`,
+	}
+
+	test.ErrorExists(t, false, r.Run(b))
+	test.ErrorExists(t, false, r.Run(b))
+	test.ErrorExists(t, false, r.Close())
+	test.Equals(t, "('2024-01-01T00:00:00Z', 50)('2024-01-01T00:01:00Z', 50);\n('2024-01-01T00:02:00Z', 50)('2024-01-01T00:03:00Z', 50);\n", buf.String())
+}
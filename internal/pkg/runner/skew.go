@@ -0,0 +1,180 @@
+package runner
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// zipfKey identifies a Zipf distribution over the rows of a source key.
+type zipfKey struct {
+	key string
+	s   float64
+}
+
+// zipf holds a Zipf distribution created for a given number of rows.
+type zipf struct {
+	rows int
+	zipf *rand.Zipf
+}
+
+// weightKey identifies the weights of the rows of a source key.
+type weightKey struct {
+	key    string
+	column string
+}
+
+// weights holds the cumulative weights of rows, calculated when they
+// were last written to.
+type weights struct {
+	cumulative []float64
+}
+
+// referenceZipf references a value from a row chosen with a Zipf
+// distribution, so that a small number of the earliest rows are chosen
+// far more often than the rest.  Larger values of s (which must be
+// greater than 1) increase the skew.
+func (s *store) referenceZipf(key, column string, exponent float64) (interface{}, error) {
+	if exponent <= 1 {
+		return nil, fmt.Errorf("zipf exponent must be greater than 1, got %v", exponent)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := s.data.len(key)
+	if n == 0 {
+		return nil, fmt.Errorf("data not found key=%q", key)
+	}
+
+	// Distributions are recreated when rows are added.
+	zk := zipfKey{key: key, s: exponent}
+	z, ok := s.zipfs[zk]
+	if !ok || z.rows != n {
+		z = zipf{
+			rows: n,
+			zipf: rand.NewZipf(rand.New(rand.NewSource(rand.Int63())), exponent, 1, uint64(n-1)),
+		}
+		s.zipfs[zk] = z
+	}
+
+	return s.value(key, column, int(z.zipf.Uint64()))
+}
+
+// referenceHot references a value from a row chosen from a hot set of
+// rows with the given probability, or from the remaining rows otherwise.
+// The hot set is the given fraction of the earliest rows.
+func (s *store) referenceHot(key, column string, fraction, probability float64) (interface{}, error) {
+	if fraction <= 0 || fraction > 1 {
+		return nil, fmt.Errorf("hot set fraction must be between 0 and 1, got %v", fraction)
+	}
+	if probability < 0 || probability > 1 {
+		return nil, fmt.Errorf("hot set probability must be between 0 and 1, got %v", probability)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	n := s.data.len(key)
+	if n == 0 {
+		return nil, fmt.Errorf("data not found key=%q", key)
+	}
+
+	hot := int(float64(n) * fraction)
+	if hot < 1 {
+		hot = 1
+	}
+
+	if hot == n || rand.Float64() < probability {
+		return s.value(key, column, rand.Intn(hot))
+	}
+	return s.value(key, column, hot+rand.Intn(n-hot))
+}
+
+// referenceWeighted references a value from a row chosen with a
+// probability proportional to the numeric value of another of its
+// columns.
+func (s *store) referenceWeighted(key, column, weightColumn string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := s.data.len(key)
+	if n == 0 {
+		return nil, fmt.Errorf("data not found key=%q", key)
+	}
+
+	// Weights are recalculated after rows are added or replaced.
+	wk := weightKey{key: key, column: weightColumn}
+	w, ok := s.weights[wk]
+	if !ok {
+		w = weights{cumulative: make([]float64, n)}
+
+		var total float64
+		for i := 0; i < n; i++ {
+			row, err := s.data.get(key, i)
+			if err != nil {
+				return nil, err
+			}
+
+			weight, err := toFloat(unwrap(row[weightColumn]))
+			if err != nil || weight < 0 {
+				return nil, fmt.Errorf("invalid weight key=%q column=%q index=%d: %v", key, weightColumn, i, row[weightColumn])
+			}
+
+			total += weight
+			w.cumulative[i] = total
+		}
+
+		if total == 0 {
+			return nil, fmt.Errorf("no weights found key=%q column=%q", key, weightColumn)
+		}
+		s.weights[wk] = w
+	}
+
+	target := rand.Float64() * w.cumulative[n-1]
+	index := sort.Search(n, func(i int) bool { return w.cumulative[i] > target })
+
+	return s.value(key, column, index)
+}
+
+// value returns the value of a column of a row.
+func (s *store) value(key, column string, index int) (interface{}, error) {
+	row, err := s.data.get(key, index)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := row[column]
+	if !ok {
+		return nil, fmt.Errorf("data not found key=%q column=%q index=%d", key, column, index)
+	}
+
+	return value, nil
+}
+
+// toFloat converts the numeric values returned by databases and
+// generators into a float64.
+func toFloat(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case json.Number:
+		return v.Float64()
+	case []byte:
+		return strconv.ParseFloat(string(v), 64)
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("%T is not a number", v)
+	}
+}
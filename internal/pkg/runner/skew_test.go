@@ -0,0 +1,115 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func newSkewStore(rows int) *store {
+	s := newStore()
+	for i := 0; i < rows; i++ {
+		s.set("owner", map[string]interface{}{"id": i, "weight": float64(i % 2)})
+	}
+	return s
+}
+
+func TestReferenceZipf(t *testing.T) {
+	s := newSkewStore(1000)
+
+	counts := map[int]int{}
+	for i := 0; i < 10000; i++ {
+		v, err := s.referenceZipf("owner", "id", 1.5)
+		test.ErrorExists(t, false, err)
+		counts[v.(int)]++
+	}
+
+	// The first row is chosen far more often than the rest.
+	test.Assert(t, counts[0] > counts[1])
+	test.Assert(t, counts[0] > 10000/3)
+
+	// Distributions are recreated as rows are added.
+	s.set("owner", map[string]interface{}{"id": 1000})
+	_, err := s.referenceZipf("owner", "id", 1.5)
+	test.ErrorExists(t, false, err)
+	test.Equals(t, 1001, s.zipfs[zipfKey{key: "owner", s: 1.5}].rows)
+}
+
+func TestReferenceHot(t *testing.T) {
+	s := newSkewStore(100)
+
+	var hot int
+	for i := 0; i < 10000; i++ {
+		v, err := s.referenceHot("owner", "id", 0.1, 0.9)
+		test.ErrorExists(t, false, err)
+		if v.(int) < 10 {
+			hot++
+		}
+	}
+
+	test.Assert(t, hot > 8500 && hot < 9500)
+}
+
+func TestReferenceWeighted(t *testing.T) {
+	s := newSkewStore(100)
+
+	for i := 0; i < 1000; i++ {
+		v, err := s.referenceWeighted("owner", "id", "weight")
+		test.ErrorExists(t, false, err)
+
+		// Only odd rows have a weight.
+		test.Equals(t, 1, v.(int)%2)
+	}
+}
+
+func TestReferenceWeightedKeep(t *testing.T) {
+	s := newStore()
+	s.keep("owner", parse.Keep{Limit: 2})
+
+	for i := 0; i < 200; i++ {
+		test.ErrorExists(t, false, s.set("owner", map[string]interface{}{"id": i, "weight": float64(i % 2)}))
+
+		// Replacing kept rows doesn't change the number of rows, but
+		// must still change the weights.
+		var total float64
+		for j := 0; j < s.data.len("owner"); j++ {
+			row, err := s.data.get("owner", j)
+			test.ErrorExists(t, false, err)
+			total += row["weight"].(float64)
+		}
+
+		v, err := s.referenceWeighted("owner", "id", "weight")
+		test.ErrorExists(t, total == 0, err)
+		if err == nil {
+			test.Equals(t, 1, v.(int)%2)
+		}
+	}
+}
+
+func TestReferenceSkewErrors(t *testing.T) {
+	s := newSkewStore(10)
+	s.set("pet", map[string]interface{}{"id": 1, "weight": "heavy"})
+
+	cases := []struct {
+		name string
+		ref  func() (interface{}, error)
+	}{
+		{name: "zipf key not found", ref: func() (interface{}, error) { return s.referenceZipf("missing", "id", 1.1) }},
+		{name: "zipf column not found", ref: func() (interface{}, error) { return s.referenceZipf("owner", "missing", 1.1) }},
+		{name: "zipf exponent too small", ref: func() (interface{}, error) { return s.referenceZipf("owner", "id", 1) }},
+		{name: "hot key not found", ref: func() (interface{}, error) { return s.referenceHot("missing", "id", 0.2, 0.8) }},
+		{name: "hot invalid fraction", ref: func() (interface{}, error) { return s.referenceHot("owner", "id", 0, 0.8) }},
+		{name: "hot invalid probability", ref: func() (interface{}, error) { return s.referenceHot("owner", "id", 0.2, 2) }},
+		{name: "weighted key not found", ref: func() (interface{}, error) { return s.referenceWeighted("missing", "id", "weight") }},
+		{name: "weighted column not a number", ref: func() (interface{}, error) { return s.referenceWeighted("pet", "id", "weight") }},
+		{name: "weighted column not found", ref: func() (interface{}, error) { return s.referenceWeighted("owner", "id", "missing") }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := c.ref()
+			test.ErrorExists(t, true, err)
+		})
+	}
+}
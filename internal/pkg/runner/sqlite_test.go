@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestRunSQLite(t *testing.T) {
+	sqlite, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "sandbox.db"))
+	if err != nil {
+		t.Fatalf("error opening sqlite: %v", err)
+	}
+	defer sqlite.Close()
+
+	_, err = sqlite.Exec(`
+		create table "owner" ("id" integer primary key autoincrement, "name" text, "date_of_birth" timestamp, "nickname");
+		create table "pet" ("id" integer primary key autoincrement, "pid" integer not null references "owner" ("id"), "name" text);`)
+	if err != nil {
+		t.Fatalf("error creating tables: %v", err)
+	}
+
+	r := mustNew(t, sink.NewDatabase(sqlite), WithDateFormat("2006-01-02"))
+
+	blocks := []parse.Block{
+		{
+			Repeat: 2,
+			Name:   "owner",
+			Body: `insert into "owner" ("name", "date_of_birth", "nickname") values
+			{{range $i, $e := ntimes 5}}{{if $i}},{{end}}('{{name}}', '{{date "1900-01-01" "now" ""}}', {{if $i}}'{{adj}}'{{else}}null{{end}}){{end}}
+			returning "id", "name", "date_of_birth", "nickname"`,
+		},
+		{
+			Repeat: 2,
+			Name:   "pet",
+			Body: `insert into "pet" ("pid", "name") values
+			{{range $i, $e := ntimes 10}}{{if $i}},{{end}}({{each "owner" "id" $i}}, '{{noun}}'){{end}}`,
+		},
+	}
+
+	for _, b := range blocks {
+		r.ResetEach(b.Name)
+		for i := 0; i < b.Repeat; i++ {
+			test.ErrorExists(t, false, r.Run(b))
+		}
+	}
+	test.ErrorExists(t, false, r.Close())
+
+	var owners, pets, orphans int
+	test.ErrorExists(t, false, sqlite.QueryRow(`select count(*) from "owner"`).Scan(&owners))
+	test.ErrorExists(t, false, sqlite.QueryRow(`select count(*) from "pet"`).Scan(&pets))
+	test.ErrorExists(t, false, sqlite.QueryRow(`select count(*) from "pet" where "pid" not in (select "id" from "owner")`).Scan(&orphans))
+
+	test.Equals(t, 10, owners)
+	test.Equals(t, 20, pets)
+	test.Equals(t, 0, orphans)
+}
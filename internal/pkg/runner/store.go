@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"math/rand"
 	"sync"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
 )
 
 type groupKey struct {
@@ -11,43 +13,152 @@ type groupKey struct {
 	groupID   int
 }
 
+// cursorKey identifies the rows of a source key being walked by each,
+// from a given block.
+type cursorKey struct {
+	block string
+	key   string
+}
+
+// cursor walks the rows of a source key sequentially.  Each group seen
+// within an execution of a block is assigned the next row, so all of the
+// columns read for a group come from the same row, whatever order
+// they're read in.
+type cursor struct {
+	next     int
+	assigned map[int]int
+}
+
 // store holds row data that comes out of the database during runtime.
 type store struct {
 	mu          sync.RWMutex
-	data        map[string][]map[string]interface{}
+	data        backend
 	group       map[groupKey]map[string]interface{}
 	eachContext string
-	eachRow     int
+	cursors     map[cursorKey]*cursor
 
-	firstColumn  string
-	currentGroup int
+	keeps map[string]parse.Keep
+	seen  map[string]int
+
+	zipfs   map[zipfKey]zipf
+	weights map[weightKey]weights
 }
 
 func newStore() *store {
+	return newStoreWithBackend(newMemoryBackend())
+}
+
+func newStoreWithBackend(b backend) *store {
 	return &store{
-		data:  map[string][]map[string]interface{}{},
-		group: map[groupKey]map[string]interface{}{},
+		data:    b,
+		group:   map[groupKey]map[string]interface{}{},
+		cursors: map[cursorKey]*cursor{},
+		keeps:   map[string]parse.Keep{},
+		seen:    map[string]int{},
+		zipfs:   map[zipfKey]zipf{},
+		weights: map[weightKey]weights{},
+	}
+}
+
+// keep bounds the rows held for a group.
+func (s *store) keep(groupName string, k parse.Keep) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keeps[groupName] = k
+}
+
+func (s *store) set(groupName string, rows map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Kept rows can be replaced without the number of rows changing, so
+	// weights are discarded whenever a group is written to.
+	for wk := range s.weights {
+		if wk.key == groupName {
+			delete(s.weights, wk)
+		}
+	}
+
+	k, ok := s.keeps[groupName]
+	if !ok {
+		return s.data.append(groupName, rows)
+	}
+
+	if len(k.Columns) > 0 {
+		kept := make(map[string]interface{}, len(k.Columns))
+		for _, c := range k.Columns {
+			if v, ok := rows[c]; ok {
+				kept[c] = v
+			}
+		}
+		rows = kept
+	}
+
+	s.seen[groupName]++
+	if k.Limit == 0 || s.data.len(groupName) < k.Limit {
+		return s.data.append(groupName, rows)
+	}
+
+	// Once the limit's reached, replace kept rows with a decreasing
+	// probability, so the rows kept are a uniformly random sample of all
+	// of those seen (reservoir sampling).
+	if i := rand.Intn(s.seen[groupName]); i < k.Limit {
+		return s.data.replace(groupName, i, rows)
+	}
+	return nil
+}
+
+// rows returns a copy of the rows held for each group, with values
+// transformed by f.
+func (s *store) rows(f func(interface{}) interface{}) (map[string][]map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	groups := s.data.groups()
+	output := make(map[string][]map[string]interface{}, len(groups))
+	for _, group := range groups {
+		for i := 0; i < s.data.len(group); i++ {
+			row, err := s.data.get(group, i)
+			if err != nil {
+				return nil, err
+			}
+
+			curr := make(map[string]interface{}, len(row))
+			for k, v := range row {
+				curr[k] = f(v)
+			}
+			output[group] = append(output[group], curr)
+		}
 	}
+
+	return output, nil
 }
 
-func (s *store) set(groupName string, rows map[string]interface{}) {
+// close releases any resources held by the store's backend.
+func (s *store) close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.data[groupName] = append(s.data[groupName], rows)
+	return s.data.close()
 }
 
 func (s *store) reference(key string, column string) (interface{}, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	rows, ok := s.data[key]
-	if !ok {
+	n := s.data.len(key)
+	if n == 0 {
 		return nil, fmt.Errorf("data not found key=%q", key)
 	}
 
-	index := rand.Intn(len(rows))
-	value, ok := rows[index][column]
+	index := rand.Intn(n)
+	row, err := s.data.get(key, index)
+	if err != nil {
+		return nil, err
+	}
+
+	value, ok := row[column]
 	if !ok {
 		return nil, fmt.Errorf("data not found key=%q column=%q index=%d", key, column, index)
 	}
@@ -56,8 +167,8 @@ func (s *store) reference(key string, column string) (interface{}, error) {
 }
 
 func (s *store) row(key, column string, group int) (interface{}, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
 	groupKey := groupKey{groupType: key, groupID: group}
 
@@ -71,8 +182,16 @@ func (s *store) row(key, column string, group int) (interface{}, error) {
 		return value, nil
 	}
 
+	n := s.data.len(key)
+	if n == 0 {
+		return nil, fmt.Errorf("data not found key=%q", key)
+	}
+
 	// Get a random item from the row context and cache it for the next read.
-	randomValue := s.data[key][rand.Intn(len(s.data[key]))]
+	randomValue, err := s.data.get(key, rand.Intn(n))
+	if err != nil {
+		return nil, err
+	}
 
 	s.group[groupKey] = randomValue
 
@@ -85,24 +204,37 @@ func (s *store) row(key, column string, group int) (interface{}, error) {
 }
 
 func (s *store) each(key, column string, group int) (interface{}, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	groupKey := groupKey{groupType: key, groupID: group}
+	n := s.data.len(key)
+	if n == 0 {
+		return nil, fmt.Errorf("data not found key=%q", key)
+	}
 
-	if s.firstColumn == "" {
-		s.firstColumn = column
-	} else {
-		if s.firstColumn == column {
-			s.eachRow++
-		}
+	ck := cursorKey{block: s.eachContext, key: key}
+	c, ok := s.cursors[ck]
+	if !ok {
+		c = &cursor{assigned: map[int]int{}}
+		s.cursors[ck] = c
+	}
+
+	// Assign the next row to new groups, returning to row 0 if we're
+	// generating more child records than parents.
+	index, ok := c.assigned[group]
+	if !ok {
+		index = c.next % n
+		c.assigned[group] = index
+		c.next++
 	}
 
-	// Get the next row from the referenced data set, returning to row 0 if we're generating
-	// more child records than parents.
-	rowRef := s.data[key][s.eachRow%len(s.data[key])]
+	rowRef, err := s.data.get(key, index)
+	if err != nil {
+		return nil, err
+	}
 
-	s.group[groupKey] = rowRef
+	// Allow row to read other columns from the same row.
+	s.group[groupKey{groupType: key, groupID: group}] = rowRef
 
 	value, ok := rowRef[column]
 	if !ok {
@@ -111,3 +243,29 @@ func (s *store) each(key, column string, group int) (interface{}, error) {
 
 	return value, nil
 }
+
+// resetEach starts walking rows from the first row for each of a block's
+// cursors.
+func (s *store) resetEach(block string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ck := range s.cursors {
+		if ck.block == block {
+			delete(s.cursors, ck)
+		}
+	}
+	s.eachContext = block
+}
+
+// nextEach forgets the rows assigned to groups by a previous execution
+// of a block, so the same groups are assigned new rows, continuing from
+// where the previous execution left off.
+func (s *store) nextEach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.cursors {
+		c.assigned = map[int]int{}
+	}
+}
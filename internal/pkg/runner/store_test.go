@@ -1,8 +1,10 @@
 package runner
 
 import (
+	"fmt"
 	"testing"
 
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
 	"github.com/codingconcepts/datagen/internal/pkg/test"
 )
 
@@ -98,3 +100,115 @@ func TestEach(t *testing.T) {
 		})
 	}
 }
+
+func TestKeep(t *testing.T) {
+	cases := []struct {
+		name       string
+		keep       parse.Keep
+		expLen     int
+		expColumns int
+	}{
+		{name: "limit", keep: parse.Keep{Limit: 10}, expLen: 10, expColumns: 2},
+		{name: "limit above rows", keep: parse.Keep{Limit: 1000}, expLen: 100, expColumns: 2},
+		{name: "columns", keep: parse.Keep{Columns: []string{"id"}}, expLen: 100, expColumns: 1},
+		{name: "limit and columns", keep: parse.Keep{Limit: 5, Columns: []string{"id", "missing"}}, expLen: 5, expColumns: 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := newStore()
+			s.keep("owner", c.keep)
+
+			for i := 0; i < 100; i++ {
+				s.set("owner", map[string]interface{}{"id": i, "name": "Alice"})
+			}
+
+			test.Equals(t, c.expLen, len(s.data.(*memoryBackend).data["owner"]))
+			for _, row := range s.data.(*memoryBackend).data["owner"] {
+				test.Equals(t, c.expColumns, len(row))
+			}
+		})
+	}
+}
+
+func TestKeepSampleIsUniform(t *testing.T) {
+	const rows, limit, runs = 100, 10, 2000
+
+	counts := make([]int, rows)
+	for run := 0; run < runs; run++ {
+		s := newStore()
+		s.keep("owner", parse.Keep{Limit: limit})
+		for i := 0; i < rows; i++ {
+			s.set("owner", map[string]interface{}{"id": i})
+		}
+
+		for _, row := range s.data.(*memoryBackend).data["owner"] {
+			counts[row["id"].(int)]++
+		}
+	}
+
+	// Each row is expected to be kept limit/rows of the time, so compare
+	// the first and second halves of the rows, which would differ wildly
+	// if early (or late) rows were favoured.
+	var first, second int
+	for i, c := range counts {
+		if i < rows/2 {
+			first += c
+		} else {
+			second += c
+		}
+	}
+
+	exp := runs * limit / 2
+	test.Assert(t, first > exp*9/10 && first < exp*11/10)
+	test.Assert(t, second > exp*9/10 && second < exp*11/10)
+}
+
+func TestEachCursors(t *testing.T) {
+	s := newStore()
+	for i := 0; i < 3; i++ {
+		s.set("owner", map[string]interface{}{"id": i, "name": fmt.Sprintf("owner %d", i)})
+	}
+	for i := 0; i < 2; i++ {
+		s.set("shop", map[string]interface{}{"id": i * 10})
+	}
+
+	each := func(key, column string, group int) interface{} {
+		v, err := s.each(key, column, group)
+		test.ErrorExists(t, false, err)
+		return v
+	}
+
+	s.resetEach("pet")
+
+	// Columns of a group come from the same row, whatever order they're
+	// read in, and each key is walked independently.
+	test.Equals(t, "owner 0", each("owner", "name", 0))
+	test.Equals(t, 0, each("shop", "id", 0))
+	test.Equals(t, 0, each("owner", "id", 0))
+	test.Equals(t, 1, each("owner", "id", 1))
+	test.Equals(t, 10, each("shop", "id", 1))
+	test.Equals(t, "owner 1", each("owner", "name", 1))
+
+	// A new execution continues from the next row, wrapping around.
+	s.nextEach()
+	test.Equals(t, 2, each("owner", "id", 0))
+	test.Equals(t, 0, each("shop", "id", 0))
+	test.Equals(t, 0, each("owner", "id", 1))
+
+	// Row reads from the row assigned by each.
+	row, err := s.row("owner", "name", 1)
+	test.ErrorExists(t, false, err)
+	test.Equals(t, "owner 0", row)
+
+	// Cursors of other blocks are unaffected by a reset.
+	s.resetEach("toy")
+	s.nextEach()
+	test.Equals(t, 0, each("owner", "id", 0))
+
+	s.eachContext = "pet"
+	test.Equals(t, 1, each("owner", "id", 0))
+
+	s.resetEach("pet")
+	test.Equals(t, 0, each("owner", "id", 0))
+}
@@ -0,0 +1,141 @@
+package runner
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// uniqueSet records the values generated for a unique key.
+type uniqueSet interface {
+	// add records a value, returning false if it may have been recorded
+	// already.
+	add(v string) bool
+}
+
+// exactSet records every value generated.
+type exactSet map[string]struct{}
+
+func (s exactSet) add(v string) bool {
+	if _, ok := s[v]; ok {
+		return false
+	}
+
+	s[v] = struct{}{}
+	return true
+}
+
+// bloomFilter records values in a fixed amount of memory.  Values that
+// are unique will occasionally be reported as seen, so will be retried,
+// but duplicates are never reported as unique.
+type bloomFilter struct {
+	bits   []uint64
+	hashes uint64
+	seeds  [2]maphash.Seed
+}
+
+// newBloomFilter returns a bloomFilter sized to hold n values with the
+// given rate of false positives.
+func newBloomFilter(n int, falsePositiveRate float64) *bloomFilter {
+	m := math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := math.Max(1, math.Round(m/float64(n)*math.Ln2))
+
+	return &bloomFilter{
+		bits:   make([]uint64, (uint64(m)+63)/64),
+		hashes: uint64(k),
+		seeds:  [2]maphash.Seed{maphash.MakeSeed(), maphash.MakeSeed()},
+	}
+}
+
+func (f *bloomFilter) add(v string) bool {
+	// Derive each of the hashes from two independent hashes.
+	h1, h2 := maphash.String(f.seeds[0], v), maphash.String(f.seeds[1], v)
+
+	size := uint64(len(f.bits) * 64)
+	added := false
+	for i := uint64(0); i < f.hashes; i++ {
+		bit := (h1 + i*h2) % size
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if f.bits[word]&mask == 0 {
+			f.bits[word] |= mask
+			added = true
+		}
+	}
+
+	return added
+}
+
+// unique calls the named template function until it returns a value that
+// hasn't been returned for the given key before.
+func (r *Runner) unique(key, name string, args ...interface{}) (interface{}, error) {
+	fn, ok := r.funcs[name]
+	if !ok || name == "unique" {
+		return nil, fmt.Errorf("function %q not found", name)
+	}
+
+	set, ok := r.uniques[key]
+	if !ok {
+		if r.uniqueFilterSize > 0 {
+			set = newBloomFilter(r.uniqueFilterSize, r.uniqueFilterRate)
+		} else {
+			set = exactSet{}
+		}
+		r.uniques[key] = set
+	}
+
+	for i := 0; i < r.uniqueAttempts; i++ {
+		v, err := callFunc(fn, args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "calling %q", name)
+		}
+
+		if set.add(fmt.Sprint(unwrap(v))) {
+			return v, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no unique value generated for %q after %d attempts, %q may have run out of values", key, r.uniqueAttempts, name)
+}
+
+// callFunc calls a template function, converting its arguments to the
+// types it expects where possible, as text/template does.
+func callFunc(fn interface{}, args ...interface{}) (interface{}, error) {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+
+	if ft.IsVariadic() && len(args) < ft.NumIn()-1 || !ft.IsVariadic() && len(args) != ft.NumIn() {
+		return nil, fmt.Errorf("wrong number of args: got %d want %d", len(args), ft.NumIn())
+	}
+
+	in := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var t reflect.Type
+		if ft.IsVariadic() && i >= ft.NumIn()-1 {
+			t = ft.In(ft.NumIn() - 1).Elem()
+		} else {
+			t = ft.In(i)
+		}
+
+		v := reflect.ValueOf(unwrap(arg))
+		switch {
+		case !v.IsValid():
+			v = reflect.Zero(t)
+		case v.Type().AssignableTo(t):
+		case v.Type().ConvertibleTo(t) && v.Kind() != reflect.String && t.Kind() != reflect.String:
+			v = v.Convert(t)
+		default:
+			return nil, fmt.Errorf("can't use %v (%T) as %s", arg, arg, t)
+		}
+		in[i] = v
+	}
+
+	out := fv.Call(in)
+	if len(out) == 2 && !out[1].IsNil() {
+		return nil, out[1].Interface().(error)
+	}
+
+	return out[0].Interface(), nil
+}
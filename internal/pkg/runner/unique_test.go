@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestUniqueSets(t *testing.T) {
+	cases := []struct {
+		name string
+		set  uniqueSet
+	}{
+		{name: "exact", set: exactSet{}},
+		{name: "bloom filter", set: newBloomFilter(1000, 0.001)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var rejected int
+			for i := 0; i < 1000; i++ {
+				if !c.set.add(fmt.Sprint(i)) {
+					rejected++
+				}
+			}
+
+			// Bloom filters may reject a small number of unique values.
+			test.Assert(t, rejected <= 10)
+
+			for i := 0; i < 1000; i++ {
+				test.Equals(t, false, c.set.add(fmt.Sprint(i)))
+			}
+		})
+	}
+}
+
+func TestUnique(t *testing.T) {
+	cases := []struct {
+		name    string
+		opts    []Option
+		fn      string
+		args    []interface{}
+		calls   int
+		expErr  bool
+		expVals int
+	}{
+		{name: "values until exhausted", fn: "int", args: []interface{}{1, 5}, calls: 5, expErr: true, expVals: 4},
+		{name: "bloom filter", opts: []Option{WithUniqueBloomFilter(100, 0.001)}, fn: "int", args: []interface{}{1, 5}, calls: 4, expVals: 4},
+		{name: "variadic function", fn: "set", args: []interface{}{"a", "b"}, calls: 2, expVals: 2},
+		{name: "function not found", fn: "missing", calls: 1, expErr: true},
+		{name: "unique not allowed", fn: "unique", calls: 1, expErr: true},
+		{name: "wrong number of args", fn: "int", args: []interface{}{1}, calls: 1, expErr: true},
+		{name: "wrong arg type", fn: "int", args: []interface{}{"a", 1}, calls: 1, expErr: true},
+		{name: "generator error", fn: "date", args: []interface{}{"a", "b", ""}, calls: 1, expErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := mustNew(t, nil, append([]Option{WithUniqueAttempts(1000)}, c.opts...)...)
+
+			seen := map[interface{}]struct{}{}
+			var err error
+			for i := 0; i < c.calls && err == nil; i++ {
+				var v interface{}
+				if v, err = r.unique("key", c.fn, c.args...); err == nil {
+					seen[v] = struct{}{}
+				}
+			}
+
+			test.ErrorExists(t, c.expErr, err)
+			test.Equals(t, c.expVals, len(seen))
+		})
+	}
+}
+
+func TestRunUnique(t *testing.T) {
+	buf := &bytes.Buffer{}
+	r := mustNew(t, sink.NewWriter(buf))
+
+	b := parse.Block{
+		Body: `{{range $i, $e := ntimes 10}}{{unique "code" "stringf" "%d" 0 10}}{{end}}`,
+	}
+
+	test.ErrorExists(t, false, r.Run(b))
+	test.ErrorExists(t, false, r.Close())
+
+	digits := strings.TrimSuffix(buf.String(), ";\n")
+	for i := 0; i < 10; i++ {
+		test.Equals(t, 1, strings.Count(digits, fmt.Sprint(i)))
+	}
+}
+
+func TestWithUniqueBloomFilterRate(t *testing.T) {
+	cases := []struct {
+		name     string
+		rate     float64
+		expError bool
+	}{
+		{name: "valid", rate: 0.01},
+		{name: "zero", rate: 0, expError: true},
+		{name: "negative", rate: -0.1, expError: true},
+		{name: "one", rate: 1, expError: true},
+		{name: "greater than one", rate: 1.5, expError: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r, err := New(sink.NewWriter(&bytes.Buffer{}), WithUniqueBloomFilter(100, c.rate))
+			test.ErrorExists(t, c.expError, err)
+			if err == nil {
+				test.ErrorExists(t, false, r.Close())
+			}
+		})
+	}
+}
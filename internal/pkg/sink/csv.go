@@ -0,0 +1,125 @@
+package sink
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// CSV writes records to an io.Writer as delimited rows, quoting values
+// where required.
+type CSV struct {
+	w      *csv.Writer
+	closer io.Closer
+	null   string
+
+	header        []string
+	headerWritten bool
+}
+
+// CSVOptions configures the output of a CSV writer.
+type CSVOptions struct {
+	// Delimiter separates each of the values in a row, defaults to ','.
+	Delimiter rune
+
+	// Null is written in place of nil values.
+	Null string
+
+	// Header, if provided, is written before the first row.
+	Header []string
+}
+
+// NewCSV returns a pointer to a CSV writer that writes to w.
+func NewCSV(w io.Writer, opts CSVOptions) *CSV {
+	c := &CSV{
+		w:      csv.NewWriter(w),
+		null:   opts.Null,
+		header: opts.Header,
+	}
+
+	if opts.Delimiter != 0 {
+		c.w.Comma = opts.Delimiter
+	}
+
+	return c
+}
+
+// NewCSVFile creates (or truncates) the file at path and returns a
+// pointer to a CSV writer that writes to it.  A path of "-" writes to
+// stdout.
+func NewCSVFile(path string, opts CSVOptions) (*CSV, error) {
+	w, closer, err := openOutput(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := NewCSV(w, opts)
+	c.closer = closer
+	return c, nil
+}
+
+// Write writes a collection of records, each as a single row.
+func (c *CSV) Write(records [][]interface{}) error {
+	if !c.headerWritten && len(c.header) > 0 {
+		if err := c.w.Write(c.header); err != nil {
+			return errors.Wrap(err, "writing header")
+		}
+	}
+	c.headerWritten = true
+
+	row := []string{}
+	for _, record := range records {
+		row = row[:0]
+		for _, v := range record {
+			row = append(row, c.format(v))
+		}
+
+		if err := c.w.Write(row); err != nil {
+			return errors.Wrap(err, "writing row")
+		}
+	}
+
+	return nil
+}
+
+// Close flushes any buffered rows and closes the underlying file if the
+// CSV writer owns one.
+func (c *CSV) Close() error {
+	c.w.Flush()
+	if err := c.w.Error(); err != nil {
+		return errors.Wrap(err, "flushing rows")
+	}
+
+	if c.closer != nil {
+		return c.closer.Close()
+	}
+	return nil
+}
+
+func (c *CSV) format(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return c.null
+	case []byte:
+		return string(t)
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
+
+// openOutput opens a file for writing, treating a path of "-" as
+// stdout.  The returned io.Closer is nil for stdout.
+func openOutput(path string) (io.Writer, io.Closer, error) {
+	if path == "-" {
+		return os.Stdout, nil, nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "creating file")
+	}
+	return f, f, nil
+}
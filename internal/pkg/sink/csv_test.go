@@ -0,0 +1,72 @@
+package sink
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestCSVWrite(t *testing.T) {
+	cases := []struct {
+		name    string
+		opts    CSVOptions
+		records [][]interface{}
+		exp     string
+	}{
+		{
+			name:    "without header",
+			records: [][]interface{}{{1, "a"}, {2, "b"}},
+			exp:     "1,a\n2,b\n",
+		},
+		{
+			name:    "with header",
+			opts:    CSVOptions{Header: []string{"id", "name"}},
+			records: [][]interface{}{{1, "a"}},
+			exp:     "id,name\n1,a\n",
+		},
+		{
+			name:    "quoting",
+			records: [][]interface{}{{`a,b`, `say "hi"`, "multi\nline"}},
+			exp:     "\"a,b\",\"say \"\"hi\"\"\",\"multi\nline\"\n",
+		},
+		{
+			name:    "custom delimiter",
+			opts:    CSVOptions{Delimiter: '\t'},
+			records: [][]interface{}{{1, "a,b"}},
+			exp:     "1\ta,b\n",
+		},
+		{
+			name:    "null",
+			opts:    CSVOptions{Null: `\N`},
+			records: [][]interface{}{{1, nil, []byte("b")}},
+			exp:     "1,\\N,b\n",
+		},
+		{
+			name: "header without records",
+			opts: CSVOptions{Header: []string{"id"}},
+			exp:  "id\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := NewCSV(buf, c.opts)
+
+			test.ErrorExists(t, false, w.Write(c.records))
+			test.ErrorExists(t, false, w.Close())
+			test.Equals(t, c.exp, buf.String())
+		})
+	}
+}
+
+func TestCSVWriteHeaderOnce(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewCSV(buf, CSVOptions{Header: []string{"id"}})
+
+	test.ErrorExists(t, false, w.Write([][]interface{}{{1}}))
+	test.ErrorExists(t, false, w.Write([][]interface{}{{2}}))
+	test.ErrorExists(t, false, w.Close())
+	test.Equals(t, "id\n1\n2\n", buf.String())
+}
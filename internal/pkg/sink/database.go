@@ -0,0 +1,79 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// Database is a Sink that executes statements against a database.
+type Database struct {
+	db *sql.DB
+}
+
+// NewDatabase returns a pointer to a Database Sink that executes
+// statements against the given database.
+func NewDatabase(db *sql.DB) *Database {
+	return &Database{db: db}
+}
+
+// Query executes a statement against the database, returning any rows
+// produced by it.
+func (d *Database) Query(ctx context.Context, stmt string) (*sql.Rows, error) {
+	return d.db.QueryContext(ctx, stmt)
+}
+
+// Read executes a query against the database, returning the rows it
+// produces.
+func (d *Database) Read(ctx context.Context, stmt string) (*sql.Rows, error) {
+	return d.db.QueryContext(ctx, stmt)
+}
+
+// Close is a no-op, as the lifetime of the database connection belongs
+// to the caller that opened it.
+func (d *Database) Close() error {
+	return nil
+}
+
+// Copy loads rows into a table using postgres' COPY FROM STDIN, all
+// within a single transaction.
+func (d *Database) Copy(ctx context.Context, table string, columns []string, rows [][]interface{}) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "beginning transaction")
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, copyIn(table, columns))
+	if err != nil {
+		return errors.Wrap(err, "preparing copy")
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err = stmt.ExecContext(ctx, row...); err != nil {
+			return errors.Wrap(err, "copying row")
+		}
+	}
+
+	// An empty exec flushes the buffered rows to the server.
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		return errors.Wrap(err, "flushing copy")
+	}
+
+	if err = stmt.Close(); err != nil {
+		return errors.Wrap(err, "closing copy")
+	}
+
+	return tx.Commit()
+}
+
+func copyIn(table string, columns []string) string {
+	if parts := strings.SplitN(table, ".", 2); len(parts) == 2 {
+		return pq.CopyInSchema(parts[0], parts[1], columns...)
+	}
+	return pq.CopyIn(table, columns...)
+}
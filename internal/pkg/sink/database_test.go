@@ -0,0 +1,64 @@
+package sink
+
+import (
+	"context"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestDatabaseQuery(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error creating sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("select 1").WillReturnRows(sqlmock.NewRows([]string{"a"}).AddRow(1))
+
+	d := NewDatabase(db)
+	rows, err := d.Query(context.Background(), "select 1")
+	test.ErrorExists(t, false, err)
+	defer rows.Close()
+
+	test.Assert(t, rows.Next())
+	test.ErrorExists(t, false, d.Close())
+	test.ErrorExists(t, false, mock.ExpectationsWereMet())
+}
+
+func TestDatabaseCopy(t *testing.T) {
+	cases := []struct {
+		name    string
+		table   string
+		expCopy string
+	}{
+		{name: "table", table: "owner", expCopy: `COPY "owner" \("id", "name"\) FROM STDIN`},
+		{name: "schema-qualified table", table: "public.owner", expCopy: `COPY "public"."owner" \("id", "name"\) FROM STDIN`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("error creating sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			mock.ExpectBegin()
+			prep := mock.ExpectPrepare(c.expCopy)
+			prep.ExpectExec().WithArgs(1, "a").WillReturnResult(sqlmock.NewResult(0, 1))
+			prep.ExpectExec().WithArgs(2, nil).WillReturnResult(sqlmock.NewResult(0, 1))
+			prep.ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectCommit()
+
+			d := NewDatabase(db)
+			err = d.Copy(context.Background(), c.table, []string{"id", "name"}, [][]interface{}{
+				{1, "a"},
+				{2, nil},
+			})
+			test.ErrorExists(t, false, err)
+			test.ErrorExists(t, false, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+var readerID uint64
+
+var mysqlStringReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	`'`, `\'`,
+	"\x00", `\0`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+)
+
+// LoadData streams rows to a MySQL server using LOAD DATA LOCAL INFILE
+// and a registered reader, so nothing is written to disk.
+func (d *Database) LoadData(ctx context.Context, table string, columns []string, rows [][]interface{}, opts InfileOptions) error {
+	opts = opts.withDefaults()
+
+	name := fmt.Sprintf("datagen_%d", atomic.AddUint64(&readerID, 1))
+
+	pr, pw := io.Pipe()
+	mysql.RegisterReaderHandler(name, func() io.Reader { return pr })
+	defer mysql.DeregisterReaderHandler(name)
+
+	go func() {
+		pw.CloseWithError(writeInfile(pw, rows, opts))
+	}()
+
+	// Unblocks the writer if the server never reads the data.
+	defer pr.Close()
+
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = quoteMySQL(c)
+	}
+
+	stmt := fmt.Sprintf("LOAD DATA LOCAL INFILE 'Reader::%s' INTO TABLE %s FIELDS TERMINATED BY %s LINES TERMINATED BY %s (%s)",
+		name,
+		quoteMySQL(table),
+		mysqlString(opts.FieldsTerminatedBy),
+		mysqlString(opts.LinesTerminatedBy),
+		strings.Join(quoted, ", "))
+
+	if _, err := d.db.ExecContext(ctx, stmt); err != nil {
+		return errors.Wrap(err, "loading data")
+	}
+	return nil
+}
+
+func (o InfileOptions) withDefaults() InfileOptions {
+	if o.FieldsTerminatedBy == "" {
+		o.FieldsTerminatedBy = "\t"
+	}
+	if o.LinesTerminatedBy == "" {
+		o.LinesTerminatedBy = "\n"
+	}
+	return o
+}
+
+// writeInfile encodes rows in the format expected by LOAD DATA with the
+// default ESCAPED BY '\\', escaping any terminators found in values.
+func writeInfile(w io.Writer, rows [][]interface{}, opts InfileOptions) error {
+	opts = opts.withDefaults()
+
+	pairs := []string{`\`, `\\`, "\x00", `\0`, "\n", `\n`, "\r", `\r`, "\t", `\t`}
+	for _, t := range []string{opts.FieldsTerminatedBy, opts.LinesTerminatedBy} {
+		if !strings.ContainsAny(t, "\\\x00\n\r\t") {
+			pairs = append(pairs, t, `\`+t)
+		}
+	}
+	escaper := strings.NewReplacer(pairs...)
+
+	values := []string{}
+	for _, row := range rows {
+		values = values[:0]
+		for _, v := range row {
+			switch t := v.(type) {
+			case nil:
+				values = append(values, `\N`)
+			case []byte:
+				values = append(values, escaper.Replace(string(t)))
+			default:
+				values = append(values, escaper.Replace(fmt.Sprintf("%v", t)))
+			}
+		}
+
+		line := strings.Join(values, opts.FieldsTerminatedBy) + opts.LinesTerminatedBy
+		if _, err := io.WriteString(w, line); err != nil {
+			return errors.Wrap(err, "writing row")
+		}
+	}
+
+	return nil
+}
+
+// quoteMySQL quotes an optionally database-qualified identifier.
+func quoteMySQL(identifier string) string {
+	parts := strings.Split(identifier, ".")
+	for i, p := range parts {
+		parts[i] = "`" + strings.Replace(p, "`", "``", -1) + "`"
+	}
+	return strings.Join(parts, ".")
+}
+
+// mysqlLiteral formats a value as a MySQL literal, quoting anything that
+// isn't a number or a boolean.
+func mysqlLiteral(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "NULL"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
+		return fmt.Sprint(t)
+	case []byte:
+		return mysqlString(string(t))
+	case time.Time:
+		return mysqlString(t.Format("2006-01-02 15:04:05.999999"))
+	default:
+		return mysqlString(fmt.Sprintf("%v", t))
+	}
+}
+
+// mysqlString returns a string as a quoted MySQL string literal.
+func mysqlString(s string) string {
+	return "'" + mysqlStringReplacer.Replace(s) + "'"
+}
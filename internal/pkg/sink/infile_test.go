@@ -0,0 +1,88 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestWriteInfile(t *testing.T) {
+	cases := []struct {
+		name string
+		opts InfileOptions
+		rows [][]interface{}
+		exp  string
+	}{
+		{
+			name: "defaults",
+			rows: [][]interface{}{{1, "a"}, {2, "b"}},
+			exp:  "1\ta\n2\tb\n",
+		},
+		{
+			name: "null",
+			rows: [][]interface{}{{1, nil}},
+			exp:  "1\t\\N\n",
+		},
+		{
+			name: "escaped characters",
+			rows: [][]interface{}{{"a\tb", "c\nd", `e\f`, []byte("g\x00")}},
+			exp:  "a\\tb\tc\\nd\te\\\\f\tg\\0\n",
+		},
+		{
+			name: "custom terminators",
+			opts: InfileOptions{FieldsTerminatedBy: ",", LinesTerminatedBy: ";\n"},
+			rows: [][]interface{}{{1, "a,b"}, {2, "c;"}},
+			exp:  "1,a\\,b;\n2,c;;\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			test.ErrorExists(t, false, writeInfile(buf, c.rows, c.opts))
+			test.Equals(t, c.exp, buf.String())
+		})
+	}
+}
+
+func TestDatabaseLoadData(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error creating sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`LOAD DATA LOCAL INFILE 'Reader::datagen_\d+' INTO TABLE ` + "`sandbox`.`pet`" +
+		` FIELDS TERMINATED BY ',' LINES TERMINATED BY '\\r\\n' \(` + "`pid`, `name`" + `\)`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	d := NewDatabase(db)
+	err = d.LoadData(context.Background(), "sandbox.pet", []string{"pid", "name"}, [][]interface{}{{1, "a"}, {2, "b"}}, InfileOptions{
+		FieldsTerminatedBy: ",",
+		LinesTerminatedBy:  "\r\n",
+	})
+	test.ErrorExists(t, false, err)
+	test.ErrorExists(t, false, mock.ExpectationsWereMet())
+}
+
+func TestMySQLString(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		exp   string
+	}{
+		{name: "plain", input: ",", exp: `','`},
+		{name: "tab", input: "\t", exp: `'\t'`},
+		{name: "quote", input: `'`, exp: `'\''`},
+		{name: "backslash", input: `\`, exp: `'\\'`},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			test.Equals(t, c.exp, mysqlString(c.input))
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// NDJSON writes JSON documents to an io.Writer, one per line.
+type NDJSON struct {
+	w      *bufio.Writer
+	closer io.Closer
+	buf    bytes.Buffer
+}
+
+// NewNDJSON returns a pointer to an NDJSON writer that writes to w.
+func NewNDJSON(w io.Writer) *NDJSON {
+	return &NDJSON{w: bufio.NewWriter(w)}
+}
+
+// NewNDJSONFile creates (or truncates) the file at path and returns a
+// pointer to an NDJSON writer that writes to it.  A path of "-" writes
+// to stdout.
+func NewNDJSONFile(path string) (*NDJSON, error) {
+	w, closer, err := openOutput(path)
+	if err != nil {
+		return nil, err
+	}
+
+	n := NewNDJSON(w)
+	n.closer = closer
+	return n, nil
+}
+
+// Write compacts each of the documents onto a single line and writes
+// them.
+func (n *NDJSON) Write(docs []json.RawMessage) error {
+	for _, doc := range docs {
+		n.buf.Reset()
+		if err := json.Compact(&n.buf, doc); err != nil {
+			return errors.Wrap(err, "compacting document")
+		}
+		n.buf.WriteByte('\n')
+
+		if _, err := n.w.Write(n.buf.Bytes()); err != nil {
+			return errors.Wrap(err, "writing document")
+		}
+	}
+
+	return nil
+}
+
+// Close flushes any buffered documents and closes the underlying file
+// if the NDJSON writer owns one.
+func (n *NDJSON) Close() error {
+	if err := n.w.Flush(); err != nil {
+		return errors.Wrap(err, "flushing documents")
+	}
+
+	if n.closer != nil {
+		return n.closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestNDJSONWrite(t *testing.T) {
+	cases := []struct {
+		name     string
+		docs     []json.RawMessage
+		exp      string
+		expError bool
+	}{
+		{
+			name: "single document",
+			docs: []json.RawMessage{json.RawMessage(`{"a": 1}`)},
+			exp:  "{\"a\":1}\n",
+		},
+		{
+			name: "multi-line documents",
+			docs: []json.RawMessage{
+				json.RawMessage("{\n\t\"a\": [1, 2]\n}"),
+				json.RawMessage(`"b"`),
+			},
+			exp: "{\"a\":[1,2]}\n\"b\"\n",
+		},
+		{
+			name:     "invalid document",
+			docs:     []json.RawMessage{json.RawMessage(`{"a":`)},
+			expError: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := NewNDJSON(buf)
+
+			test.ErrorExists(t, c.expError, w.Write(c.docs))
+			test.ErrorExists(t, false, w.Close())
+			if c.expError {
+				return
+			}
+			test.Equals(t, c.exp, buf.String())
+		})
+	}
+}
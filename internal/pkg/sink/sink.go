@@ -0,0 +1,46 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Sink is the destination for the statements generated by the Runner.
+type Sink interface {
+	// Query executes a statement, returning any rows it produces.  Sinks
+	// that don't talk to a database return nil rows.
+	Query(ctx context.Context, stmt string) (*sql.Rows, error)
+
+	// Close flushes and releases any resources held by the Sink.
+	Close() error
+}
+
+// Reader is implemented by Sinks that can read rows back out of a
+// database.
+type Reader interface {
+	// Read executes a query, returning the rows it produces.
+	Read(ctx context.Context, stmt string) (*sql.Rows, error)
+}
+
+// Copier is implemented by Sinks that can bulk-load rows into a table.
+type Copier interface {
+	// Copy loads rows into the given columns of a table.
+	Copy(ctx context.Context, table string, columns []string, rows [][]interface{}) error
+}
+
+// Loader is implemented by Sinks that can bulk-load rows into a table
+// using LOAD DATA LOCAL INFILE.
+type Loader interface {
+	// LoadData loads rows into the given columns of a table.
+	LoadData(ctx context.Context, table string, columns []string, rows [][]interface{}, opts InfileOptions) error
+}
+
+// InfileOptions configures how rows are encoded for LOAD DATA.
+type InfileOptions struct {
+	// FieldsTerminatedBy separates each of the values in a row, defaults
+	// to a tab.
+	FieldsTerminatedBy string
+
+	// LinesTerminatedBy separates each row, defaults to a newline.
+	LinesTerminatedBy string
+}
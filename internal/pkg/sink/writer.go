@@ -0,0 +1,146 @@
+package sink
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// Writer is a Sink that writes statements to an io.Writer, terminating
+// each with a semicolon so the output can be replayed as a SQL file.
+type Writer struct {
+	w      *bufio.Writer
+	closer io.Closer
+}
+
+// NewWriter returns a pointer to a Writer Sink that writes statements
+// to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// NewFile creates (or truncates) the file at path and returns a pointer
+// to a Writer Sink that writes statements to it.
+func NewFile(path string) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating file")
+	}
+
+	return &Writer{w: bufio.NewWriter(f), closer: f}, nil
+}
+
+// Query writes a statement, returning no rows.
+func (w *Writer) Query(_ context.Context, stmt string) (*sql.Rows, error) {
+	stmt = strings.TrimSpace(stmt)
+	if !strings.HasSuffix(stmt, ";") {
+		stmt += ";"
+	}
+
+	if _, err := w.w.WriteString(stmt + "\n"); err != nil {
+		return nil, errors.Wrap(err, "writing statement")
+	}
+	return nil, nil
+}
+
+// Copy writes rows as a COPY FROM stdin statement followed by its data
+// in postgres' text format, as produced by pg_dump and understood by
+// psql.
+func (w *Writer) Copy(_ context.Context, table string, columns []string, rows [][]interface{}) error {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = pq.QuoteIdentifier(c)
+	}
+
+	if _, err := fmt.Fprintf(w.w, "COPY %s (%s) FROM stdin;\n", quotePostgres(table), strings.Join(quoted, ", ")); err != nil {
+		return errors.Wrap(err, "writing copy statement")
+	}
+
+	for _, row := range rows {
+		values := make([]string, len(row))
+		for i, v := range row {
+			values[i] = copyText(v)
+		}
+
+		if _, err := w.w.WriteString(strings.Join(values, "\t") + "\n"); err != nil {
+			return errors.Wrap(err, "writing copy row")
+		}
+	}
+
+	_, err := w.w.WriteString("\\.\n")
+	return errors.Wrap(err, "writing copy terminator")
+}
+
+// LoadData writes rows as a multi-row MySQL INSERT statement, as LOAD
+// DATA LOCAL INFILE reads its data from the client, so can't be replayed
+// from a SQL file.
+func (w *Writer) LoadData(_ context.Context, table string, columns []string, rows [][]interface{}, _ InfileOptions) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = quoteMySQL(c)
+	}
+
+	tuples := make([]string, len(rows))
+	for i, row := range rows {
+		values := make([]string, len(row))
+		for j, v := range row {
+			values[j] = mysqlLiteral(v)
+		}
+		tuples[i] = "(" + strings.Join(values, ", ") + ")"
+	}
+
+	_, err := fmt.Fprintf(w.w, "INSERT INTO %s (%s) VALUES\n%s;\n", quoteMySQL(table), strings.Join(quoted, ", "), strings.Join(tuples, ",\n"))
+	return errors.Wrap(err, "writing insert statement")
+}
+
+// Close flushes any buffered statements and closes the underlying file
+// if the Writer owns one.
+func (w *Writer) Close() error {
+	if err := w.w.Flush(); err != nil {
+		return errors.Wrap(err, "flushing statements")
+	}
+
+	if w.closer != nil {
+		return w.closer.Close()
+	}
+	return nil
+}
+
+// quotePostgres quotes an optionally schema-qualified identifier.
+func quotePostgres(identifier string) string {
+	parts := strings.Split(identifier, ".")
+	for i, p := range parts {
+		parts[i] = pq.QuoteIdentifier(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+var copyTextReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+)
+
+// copyText formats a value in postgres' COPY text format.
+func copyText(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return `\N`
+	case []byte:
+		return copyTextReplacer.Replace(string(t))
+	default:
+		return copyTextReplacer.Replace(fmt.Sprintf("%v", t))
+	}
+}
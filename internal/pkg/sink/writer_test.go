@@ -0,0 +1,110 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+func TestWriterQuery(t *testing.T) {
+	cases := []struct {
+		name  string
+		stmts []string
+		exp   string
+	}{
+		{name: "terminated", stmts: []string{"select 1;"}, exp: "select 1;\n"},
+		{name: "unterminated", stmts: []string{"select 1"}, exp: "select 1;\n"},
+		{name: "surrounding whitespace", stmts: []string{"\n\tselect 1 \n"}, exp: "select 1;\n"},
+		{name: "multiple", stmts: []string{"select 1", "select 2;"}, exp: "select 1;\nselect 2;\n"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			w := NewWriter(buf)
+
+			for _, stmt := range c.stmts {
+				rows, err := w.Query(context.Background(), stmt)
+				test.ErrorExists(t, false, err)
+				test.Assert(t, rows == nil)
+			}
+
+			test.ErrorExists(t, false, w.Close())
+			test.Equals(t, c.exp, buf.String())
+		})
+	}
+}
+
+func TestNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seed.sql")
+
+	w, err := NewFile(path)
+	test.ErrorExists(t, false, err)
+
+	_, err = w.Query(context.Background(), "select 1")
+	test.ErrorExists(t, false, err)
+	test.ErrorExists(t, false, w.Close())
+
+	b, err := ioutil.ReadFile(path)
+	test.ErrorExists(t, false, err)
+	test.Equals(t, "select 1;\n", string(b))
+}
+
+func TestWriterCopy(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+
+	err := w.Copy(context.Background(), "owner", []string{"id", "name"}, [][]interface{}{
+		{1, "a\tb"},
+		{2, nil},
+		{3, []byte(`c\d`)},
+	})
+	test.ErrorExists(t, false, err)
+	test.ErrorExists(t, false, w.Close())
+
+	exp := "COPY \"owner\" (\"id\", \"name\") FROM stdin;\n" +
+		"1\ta\\tb\n" +
+		"2\t\\N\n" +
+		"3\tc\\\\d\n" +
+		"\\.\n"
+	test.Equals(t, exp, buf.String())
+}
+
+func TestWriterCopyQuotesIdentifiers(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+
+	err := w.Copy(context.Background(), "Sales.Order", []string{"Id", "order", `say "hi"`}, [][]interface{}{{1, 2, 3}})
+	test.ErrorExists(t, false, err)
+	test.ErrorExists(t, false, w.Close())
+
+	exp := "COPY \"Sales\".\"Order\" (\"Id\", \"order\", \"say \"\"hi\"\"\") FROM stdin;\n" +
+		"1\t2\t3\n" +
+		"\\.\n"
+	test.Equals(t, exp, buf.String())
+}
+
+func TestWriterLoadData(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w := NewWriter(buf)
+
+	err := w.LoadData(context.Background(), "shop.pet", []string{"pid", "name", "born"}, [][]interface{}{
+		{1, "it's\tme", time.Date(2020, time.January, 2, 3, 4, 5, 0, time.UTC)},
+		{int64(2), nil, []byte(`a\b`)},
+	}, InfileOptions{})
+	test.ErrorExists(t, false, err)
+
+	// Empty batches write nothing.
+	test.ErrorExists(t, false, w.LoadData(context.Background(), "pet", []string{"pid"}, nil, InfileOptions{}))
+	test.ErrorExists(t, false, w.Close())
+
+	exp := "INSERT INTO `shop`.`pet` (`pid`, `name`, `born`) VALUES\n" +
+		"(1, 'it\\'s\\tme', '2020-01-02 03:04:05'),\n" +
+		"(2, NULL, 'a\\\\b');\n"
+	test.Equals(t, exp, buf.String())
+}
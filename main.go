@@ -7,14 +7,17 @@ import (
 	"log"
 	"math/rand"
 	"os"
+	"strings"
 	"time"
 
 	"gopkg.in/cheggaaa/pb.v1"
 
 	"github.com/codingconcepts/datagen/internal/pkg/parse"
 	"github.com/codingconcepts/datagen/internal/pkg/runner"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
 	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
 )
 
 var semver string
@@ -23,11 +26,20 @@ func main() {
 	rand.Seed(time.Now().UnixNano())
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
-	driver := flag.String("driver", "", "name of the database driver to use [postgres|mysql]")
+	driver := flag.String("driver", "", "name of the database driver to use [postgres|mysql|sqlite]")
 	script := flag.String("script", "", "the full or relative path to your script file")
 	conn := flag.String("conn", "", "the database connection string")
 	dateFmt := flag.String("datefmt", "2006-01-02", "the Go date format for all database dates")
-	debug := flag.Bool("debug", false, "dry run without writing to database, ref, row, and each won't work")
+	pluginTimeout := flag.Duration("plugintimeout", time.Second*10, "the maximum time to wait for a plugin to respond or shut down")
+	batch := flag.Int("batch", 10000, "the maximum number of rows written by each COPY or LOAD DATA statement")
+	out := flag.String("out", "db", "where to write generated statements [db|stdout|path to a .sql file]")
+	debug := flag.Bool("debug", false, "dry run without writing to database (shorthand for -out stdout)")
+	store := flag.String("store", "memory", "where to keep the rows returned by each block [memory|disk]")
+	uniqueBloom := flag.Int("uniquebloom", 0, "track values generated by unique with bloom filters sized for this many values per key, rather than exactly")
+	localeCode := flag.String("locale", "", "the default locale of names, addresses and phone numbers, such as de_DE (defaults to go-randomdata's data)")
+	seqFile := flag.String("seqfile", "", "the path of a file to keep the last value of each sequence in, so subsequent runs continue from it")
+	validate := flag.Bool("validate", false, "check the script's templates without running it")
+	funcs := flag.Bool("funcs", false, "list the functions available to templates")
 	version := flag.Bool("version", false, "display the current version number")
 	flag.Parse()
 
@@ -36,15 +48,23 @@ func main() {
 		os.Exit(2)
 	}
 
-	if *script == "" || *driver == "" || *conn == "" {
-		flag.Usage()
-		os.Exit(2)
+	if *funcs {
+		r, err := runner.New(nil)
+		if err != nil {
+			log.Fatalf("error creating runner: %v", err)
+		}
+		fmt.Println(strings.Join(r.Funcs(), "\n"))
+		return
 	}
 
-	db := mustConnect(*driver, *conn)
-	defer db.Close()
+	if *debug {
+		*out = "stdout"
+	}
 
-	runner := runner.New(db, runner.WithDateFormat(*dateFmt), runner.WithDebug(*debug))
+	if *script == "" || (!*validate && *out == "db" && (*driver == "" || *conn == "")) {
+		flag.Usage()
+		os.Exit(2)
+	}
 
 	file, err := os.Open(*script)
 	if err != nil {
@@ -57,26 +77,104 @@ func main() {
 		log.Fatalf("error reading blocks from script file: %v", err)
 	}
 
+	if *validate {
+		r, err := runner.New(nil)
+		if err != nil {
+			log.Fatalf("error creating runner: %v", err)
+		}
+		if err = r.Validate(blocks); err != nil {
+			log.Fatalf("error validating script: %v", err)
+		}
+		fmt.Println("OK")
+		return
+	}
+
 	bar := newProgressBar(blocks)
+
+	var copied int
+	opts := []runner.Option{
+		runner.WithDateFormat(*dateFmt),
+		runner.WithBatchSize(*batch),
+		runner.WithPluginTimeout(*pluginTimeout),
+		runner.WithSequenceFile(*seqFile),
+		runner.WithLocale(*localeCode),
+		runner.WithDriver(*driver),
+		runner.WithProgress(func(rows int) {
+			copied += rows
+			bar.Postfix(fmt.Sprintf(" %d rows copied", copied))
+		}),
+	}
+
+	if *uniqueBloom > 0 {
+		opts = append(opts, runner.WithUniqueBloomFilter(*uniqueBloom, 0.001))
+	}
+
+	switch *store {
+	case "memory":
+	case "disk":
+		opts = append(opts, runner.WithDiskStore(""))
+	default:
+		log.Fatalf("invalid store %q", *store)
+	}
+
+	var db *sql.DB
+	if *out == "db" {
+		db = mustConnect(*driver, *conn)
+		defer db.Close()
+	}
+
+	runner, err := runner.New(mustSink(*out, db), opts...)
+	if err != nil {
+		log.Fatalf("error creating runner: %v", err)
+	}
+
 	for _, block := range blocks {
 		runner.ResetEach(block.Name)
 		for i := 0; i < block.Repeat; i++ {
 			bar.Increment()
 			if err = runner.Run(block); err != nil {
+				runner.Close()
 				log.Fatalf("error running block %q: %v", block.Name, err)
 			}
 		}
+
+		if err = runner.Flush(); err != nil {
+			runner.Close()
+			log.Fatalf("error flushing block %q: %v", block.Name, err)
+		}
+	}
+
+	if err = runner.Close(); err != nil {
+		log.Fatalf("error closing output: %v", err)
 	}
 	bar.FinishPrint("Finished")
 }
 
+func mustSink(out string, db *sql.DB) sink.Sink {
+	switch out {
+	case "db":
+		return sink.NewDatabase(db)
+	case "stdout":
+		return sink.NewWriter(os.Stdout)
+	default:
+		s, err := sink.NewFile(out)
+		if err != nil {
+			log.Fatalf("error opening output file: %v", err)
+		}
+		return s
+	}
+}
+
 func newProgressBar(blocks []parse.Block) *pb.ProgressBar {
 	var count int
 	for _, block := range blocks {
 		count += block.Repeat
 	}
 
+	// The bar is written to stderr, so that it doesn't mix with SQL and
+	// records written to stdout.
 	bar := pb.New(count)
+	bar.Output = os.Stderr
 	bar.SetRefreshRate(time.Millisecond * 100)
 	bar.ShowCounters = false
 	return bar.Start()
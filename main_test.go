@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+)
+
+// TestMainProcess isn't a real test, it's used to run datagen by the
+// other tests in this package.
+func TestMainProcess(t *testing.T) {
+	if os.Getenv("DATAGEN_MAIN_PROCESS") != "1" {
+		return
+	}
+
+	os.Args = append([]string{"datagen"}, strings.Fields(os.Getenv("DATAGEN_ARGS"))...)
+	main()
+	os.Exit(0)
+}
+
+// runMain runs datagen with a script and arguments, returning what it
+// wrote to stdout.
+func runMain(t *testing.T, script string, args ...string) string {
+	path := filepath.Join(t.TempDir(), "script.sql")
+	test.ErrorExists(t, false, os.WriteFile(path, []byte(script), 0644))
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestMainProcess")
+	cmd.Env = append(os.Environ(),
+		"DATAGEN_MAIN_PROCESS=1",
+		"DATAGEN_ARGS="+strings.Join(append([]string{"-script", path}, args...), " "))
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("error running datagen: %v\n%s", err, stderr.String())
+	}
+
+	return stdout.String()
+}
+
+func TestMainStdout(t *testing.T) {
+	script := `-- REPEAT 3
+-- NAME owner
+insert into "owner" ("id") values ({{int 1 10}})`
+
+	act := runMain(t, script, "-out", "stdout")
+
+	lines := strings.Split(strings.TrimSuffix(act, "\n"), "\n")
+	test.Equals(t, 3, len(lines))
+	for _, line := range lines {
+		test.Assert(t, strings.HasPrefix(line, `insert into "owner" ("id") values (`))
+		test.Assert(t, strings.HasSuffix(line, ");"))
+	}
+}
+
+func TestMainOutputStdout(t *testing.T) {
+	cases := []struct {
+		name   string
+		script string
+		check  func(t *testing.T, line string)
+	}{
+		{
+			name: "ndjson",
+			script: `-- REPEAT 3
+-- OUTPUT ndjson -
+{"id": {{int 1 10}}, "name": {{jsonstr (name)}}}`,
+			check: func(t *testing.T, line string) {
+				var doc map[string]interface{}
+				test.ErrorExists(t, false, json.Unmarshal([]byte(line), &doc))
+				test.Equals(t, 2, len(doc))
+			},
+		},
+		{
+			name: "csv",
+			script: `-- REPEAT 3
+-- COLUMNS id, name
+-- OUTPUT csv - header=false
+{{record (int 1 10) "a"}}`,
+			check: func(t *testing.T, line string) {
+				fields := strings.Split(line, ",")
+				test.Equals(t, 2, len(fields))
+				test.Equals(t, "a", fields[1])
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			act := runMain(t, c.script, "-out", "stdout")
+
+			lines := strings.Split(strings.TrimSuffix(act, "\n"), "\n")
+			test.Equals(t, 3, len(lines))
+			for _, line := range lines {
+				c.check(t, line)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+// Package datagen allows scripts to be run from Go code, making it
+// possible to seed databases from tests without shelling out to the
+// datagen binary:
+//
+//	script, err := datagen.LoadFile("testdata/seed.sql")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	results, err := datagen.Run(ctx, db, script)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//
+//	ownerIDs := results["owner"]
+package datagen
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"os"
+
+	"github.com/codingconcepts/datagen/internal/pkg/parse"
+	"github.com/codingconcepts/datagen/internal/pkg/runner"
+	"github.com/codingconcepts/datagen/internal/pkg/sink"
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+// Script holds the blocks parsed from a datagen script.
+type Script struct {
+	blocks []parse.Block
+}
+
+// Results holds the rows returned by, or recorded in, each named block
+// of a script, keyed by block name.
+type Results map[string][]map[string]interface{}
+
+// Load parses a script from the given reader.
+func Load(r io.Reader) (*Script, error) {
+	blocks, err := parse.Blocks(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing script")
+	}
+
+	return &Script{blocks: blocks}, nil
+}
+
+// LoadFile parses the script at the given path.
+func LoadFile(path string) (*Script, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening script")
+	}
+	defer file.Close()
+
+	return Load(file)
+}
+
+// Run executes each of the blocks in a script against a database,
+// returning the rows produced by each named block.  The database isn't
+// closed once the script has run.
+func Run(ctx context.Context, db *sql.DB, script *Script, opts ...Option) (Results, error) {
+	r, err := newRunner(sink.NewDatabase(db), append([]Option{withDriver(db)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := run(ctx, r, script); err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	results, err := r.Results()
+	if err != nil {
+		r.Close()
+		return nil, errors.Wrap(err, "reading results")
+	}
+
+	if err := r.Close(); err != nil {
+		return nil, errors.Wrap(err, "closing runner")
+	}
+
+	return Results(results), nil
+}
+
+// Validate checks that the templates of each of the blocks in a script
+// can be parsed, without running them.
+func Validate(script *Script, opts ...Option) error {
+	r, err := newRunner(nil, opts...)
+	if err != nil {
+		return err
+	}
+
+	return r.Validate(script.blocks)
+}
+
+// Funcs returns the sorted names of all of the functions available to
+// templates, including any provided as options.
+func Funcs(opts ...Option) ([]string, error) {
+	r, err := newRunner(nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.Funcs(), nil
+}
+
+// withDriver records the name of the database's driver, where it affects
+// how statements are written.
+func withDriver(db *sql.DB) Option {
+	return func(c *config) {
+		if _, ok := db.Driver().(*mysql.MySQLDriver); ok {
+			c.driver = "mysql"
+		}
+	}
+}
+
+func newRunner(s sink.Sink, opts ...Option) (*runner.Runner, error) {
+	c := config{
+		dateFormat: "2006-01-02",
+		batchSize:  10000,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	r, err := runner.New(
+		s,
+		runner.WithDateFormat(c.dateFormat),
+		runner.WithBatchSize(c.batchSize),
+		runner.WithLocale(c.locale),
+		runner.WithDriver(c.driver),
+		runner.WithQueryErrFile(""),
+		runner.WithFuncs(c.funcs),
+		runner.WithFuncOverrides(c.funcOverrides))
+	if err != nil {
+		return nil, errors.Wrap(err, "creating runner")
+	}
+
+	return r, nil
+}
+
+func run(ctx context.Context, r *runner.Runner, script *Script) error {
+	for _, block := range script.blocks {
+		r.ResetEach(block.Name)
+		for i := 0; i < block.Repeat; i++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if err := r.RunContext(ctx, block); err != nil {
+				return errors.Wrapf(err, "running block %q", block.Name)
+			}
+		}
+
+		if err := r.FlushContext(ctx); err != nil {
+			return errors.Wrapf(err, "flushing block %q", block.Name)
+		}
+	}
+
+	return nil
+}
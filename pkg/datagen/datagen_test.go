@@ -0,0 +1,152 @@
+package datagen
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/codingconcepts/datagen/internal/pkg/test"
+
+	_ "modernc.org/sqlite"
+)
+
+const script = `-- REPEAT 2
+-- NAME owner
+insert into "owner" ("name") values
+{{range $i, $e := ntimes 5}}
+	{{if $i}},{{end}}
+	('{{name}}')
+{{end}}
+returning "id", "name";
+
+-- NAME pet
+insert into "pet" ("pid", "name") values
+{{range $i, $e := ntimes 10}}
+	{{if $i}},{{end}}
+	({{each "owner" "id" $i}}, '{{noun}}')
+{{end}}
+returning "id";`
+
+func openSQLite(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "sandbox.db"))
+	if err != nil {
+		t.Fatalf("error opening sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`
+		create table "owner" ("id" integer primary key autoincrement, "name" text);
+		create table "pet" ("id" integer primary key autoincrement, "pid" integer not null, "name" text);`)
+	if err != nil {
+		t.Fatalf("error creating tables: %v", err)
+	}
+
+	return db
+}
+
+func TestRun(t *testing.T) {
+	db := openSQLite(t)
+
+	s, err := Load(strings.NewReader(script))
+	test.ErrorExists(t, false, err)
+
+	results, err := Run(context.Background(), db, s)
+	test.ErrorExists(t, false, err)
+
+	test.Equals(t, 10, len(results["owner"]))
+	test.Equals(t, 10, len(results["pet"]))
+
+	var owners int
+	test.ErrorExists(t, false, db.QueryRow(`select count(*) from "owner"`).Scan(&owners))
+	test.Equals(t, 10, owners)
+
+	// Results hold plain values rather than database/sql internals.
+	_, ok := results["owner"][0]["id"].(int64)
+	test.Assert(t, ok)
+}
+
+func TestRunCancelled(t *testing.T) {
+	db := openSQLite(t)
+
+	s, err := Load(strings.NewReader(script))
+	test.ErrorExists(t, false, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = Run(ctx, db, s)
+	test.Equals(t, context.Canceled, err)
+}
+
+func TestRunError(t *testing.T) {
+	db := openSQLite(t)
+
+	s, err := Load(strings.NewReader(`insert into "missing" ("id") values (1);`))
+	test.ErrorExists(t, false, err)
+
+	_, err = Run(context.Background(), db, s)
+	test.ErrorExists(t, true, err)
+}
+
+func TestLoadFile(t *testing.T) {
+	_, err := LoadFile(filepath.Join(t.TempDir(), "missing.sql"))
+	test.ErrorExists(t, true, err)
+}
+
+func TestRunWithFuncs(t *testing.T) {
+	db := openSQLite(t)
+
+	s, err := Load(strings.NewReader(`-- NAME owner
+	insert into "owner" ("name") values ('{{sku}}') returning "name";`))
+	test.ErrorExists(t, false, err)
+
+	results, err := Run(context.Background(), db, s, WithFuncs(template.FuncMap{
+		"sku": func() string { return "SKU-123" },
+	}))
+	test.ErrorExists(t, false, err)
+	test.Equals(t, "SKU-123", results["owner"][0]["name"])
+
+	_, err = Run(context.Background(), db, s, WithFuncs(template.FuncMap{
+		"name": func() string { return "SKU-123" },
+	}))
+	test.ErrorExists(t, true, err)
+}
+
+func TestRunWithFuncsRepeated(t *testing.T) {
+	db := openSQLite(t)
+
+	s, err := Load(strings.NewReader(`-- NAME owner
+	insert into "owner" ("name") values ('{{sku}}-{{barcode}}') returning "name";`))
+	test.ErrorExists(t, false, err)
+
+	results, err := Run(context.Background(), db, s,
+		WithFuncs(template.FuncMap{"sku": func() string { return "SKU-123" }}),
+		WithFuncs(template.FuncMap{"barcode": func() string { return "456" }}),
+	)
+	test.ErrorExists(t, false, err)
+	test.Equals(t, "SKU-123-456", results["owner"][0]["name"])
+}
+
+func TestValidate(t *testing.T) {
+	s, err := Load(strings.NewReader(`select '{{sku}}';`))
+	test.ErrorExists(t, false, err)
+
+	test.ErrorExists(t, true, Validate(s))
+	test.ErrorExists(t, false, Validate(s, WithFuncs(template.FuncMap{
+		"sku": func() string { return "SKU-123" },
+	})))
+}
+
+func TestFuncs(t *testing.T) {
+	funcs, err := Funcs(WithFuncs(template.FuncMap{"sku": func() string { return "" }}))
+	test.ErrorExists(t, false, err)
+
+	var found bool
+	for _, f := range funcs {
+		found = found || f == "sku"
+	}
+	test.Assert(t, found)
+}
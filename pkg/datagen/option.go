@@ -0,0 +1,70 @@
+package datagen
+
+import "text/template"
+
+type config struct {
+	dateFormat    string
+	batchSize     int
+	locale        string
+	driver        string
+	funcs         template.FuncMap
+	funcOverrides template.FuncMap
+}
+
+// Option allows a script run to be configured by the user.
+type Option func(*config)
+
+// WithDateFormat sets the Go date format for all database and template
+// dates.  Defaults to "2006-01-02".
+func WithDateFormat(f string) Option {
+	return func(c *config) {
+		c.dateFormat = f
+	}
+}
+
+// WithBatchSize sets the maximum number of records written by each
+// COPY or LOAD DATA statement.  Defaults to 10000.
+func WithBatchSize(n int) Option {
+	return func(c *config) {
+		c.batchSize = n
+	}
+}
+
+// WithLocale sets the default locale of personal data, such as names and
+// addresses, for template functions that aren't given one.
+func WithLocale(code string) Option {
+	return func(c *config) {
+		c.locale = code
+	}
+}
+
+// WithFuncs adds custom functions to those available to templates.  An
+// error is returned if any of the functions share their name with a
+// built-in function.  Functions from repeated calls are combined.
+func WithFuncs(funcs template.FuncMap) Option {
+	return func(c *config) {
+		c.funcs = mergeFuncs(c.funcs, funcs)
+	}
+}
+
+// WithFuncOverrides adds custom functions to those available to
+// templates, replacing any built-in functions that share their names.
+// Functions from repeated calls are combined.
+func WithFuncOverrides(funcs template.FuncMap) Option {
+	return func(c *config) {
+		c.funcOverrides = mergeFuncs(c.funcOverrides, funcs)
+	}
+}
+
+// mergeFuncs returns a copy of dst with the functions in src added,
+// leaving the caller's maps untouched.
+func mergeFuncs(dst, src template.FuncMap) template.FuncMap {
+	merged := make(template.FuncMap, len(dst)+len(src))
+	for name, f := range dst {
+		merged[name] = f
+	}
+	for name, f := range src {
+		merged[name] = f
+	}
+	return merged
+}